@@ -0,0 +1,34 @@
+package actions
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/speakeasy-api/sdk-generation-action/internal/environment"
+	"github.com/speakeasy-api/sdk-generation-action/internal/logging"
+)
+
+func Cleanup(cfg *environment.Config) error {
+	g, err := initAction(cfg)
+	if err != nil {
+		return err
+	}
+	defer g.PrintPlan()
+
+	result, err := g.Cleanup(cfg.CleanupMaxAge)
+	if err != nil {
+		return err
+	}
+
+	closedPRs := []string{}
+	for _, pr := range result.ClosedPRs {
+		closedPRs = append(closedPRs, strconv.Itoa(pr.Number))
+	}
+
+	logging.Info("Closed %d superseded PR(s) and deleted %d stale branch(es)", len(result.ClosedPRs), len(result.DeletedBranches))
+
+	return setOutputs(cfg, map[string]string{
+		"deleted_branches": strings.Join(result.DeletedBranches, ","),
+		"closed_prs":       strings.Join(closedPRs, ","),
+	})
+}
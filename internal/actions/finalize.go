@@ -1,43 +1,62 @@
 package actions
 
 import (
-	"errors"
+	"fmt"
+	"strconv"
 
 	"github.com/speakeasy-api/sdk-generation-action/internal/cli"
 	"github.com/speakeasy-api/sdk-generation-action/internal/environment"
+	"github.com/speakeasy-api/sdk-generation-action/internal/git"
 	"github.com/speakeasy-api/sdk-generation-action/internal/logging"
+	"github.com/speakeasy-api/sdk-generation-action/internal/openapi"
 	"github.com/speakeasy-api/sdk-generation-action/pkg/releases"
 )
 
-func Finalize() error {
-	g, err := initAction()
+func Finalize(cfg *environment.Config) error {
+	g, err := initAction(cfg)
 	if err != nil {
 		return err
 	}
+	defer g.PrintPlan()
 
-	branchName := environment.GetBranchName()
-	if branchName == "" {
-		return errors.New("branch name is required")
+	if cfg.ConcurrencyLock {
+		if err := g.AcquireLock(); err != nil {
+			return err
+		}
+		defer releaseLock(g)
 	}
 
+	branchName := cfg.BranchName
+
 	success := false
+	// keepBranch is set when a PR is opened for the branch in direct mode as it couldn't be merged
+	keepBranch := false
 
 	defer func() {
-		if (!success || environment.GetMode() == environment.ModeDirect) && !environment.IsDebugMode() {
+		if (!success || (cfg.Mode == environment.ModeDirect && !keepBranch)) && !cfg.Debug {
 			if err := g.DeleteBranch(branchName); err != nil {
 				logging.Debug("failed to delete branch %s: %v", branchName, err)
 			}
 		}
 	}()
 
+	if cfg.DryRun {
+		if err := g.PlanFinalize(branchName); err != nil {
+			return err
+		}
+
+		success = true
+		return nil
+	}
+
 	branchName, err = g.FindBranch(branchName)
 	if err != nil {
 		return err
 	}
 
-	switch environment.GetMode() {
+	switch cfg.Mode {
 	case environment.ModePR:
-		if err := cli.Download(environment.GetPinnedSpeakeasyVersion(), g); err != nil {
+		if err := cli.Download(cfg, g); err != nil {
 			return err
 		}
 
@@ -46,38 +65,161 @@ func Finalize() error {
 			return err
 		}
 
-		releaseInfo, err := getReleasesInfo()
+		history, err := getReleases(cfg)
+		if err != nil {
+			return err
+		}
+
+		releaseInfo, previous, err := splitLatestRelease(history)
+		if err != nil {
+			return err
+		}
+
+		changeReport, err := openapi.ParseReport(cfg.OpenAPIChangeReport)
+		if err != nil {
+			return err
+		}
+
+		pr, err = g.CreateOrUpdatePR(branchName, *releaseInfo, previous, cfg.PreviousGenVersion, changeReport, pr)
+		if err != nil {
+			return err
+		}
+
+		resolvedVersion, err := cli.GetSpeakeasyVersion()
 		if err != nil {
 			return err
 		}
 
-		if err := g.CreateOrUpdatePR(branchName, *releaseInfo, environment.GetPreviousGenVersion(), pr); err != nil {
+		outputs := map[string]string{
+			"resolved_speakeasy_version": resolvedVersion.String(),
+			"pr_url":                     pr.URL,
+		}
+
+		if cfg.AutoMerge {
+			// The version_bump output of the generate action covers unpublished languages, the release history is a fallback for workflows that don't pass it
+			bump := history.LatestBump()
+			if cfg.VersionBump != "" {
+				bump, _ = releases.ParseBump(cfg.VersionBump)
+			}
+
+			enabled, err := g.SyncAutoMerge(pr, bump)
+			if err != nil {
+				return err
+			}
+
+			outputs["auto_merge_enabled"] = strconv.FormatBool(enabled)
+		}
+
+		if err := setOutputs(cfg, outputs); err != nil {
 			return err
 		}
 	case environment.ModeDirect:
-		releaseInfo, err := getReleasesInfo()
+		history, err := getReleases(cfg)
 		if err != nil {
 			return err
 		}
 
-		commitHash, err := g.MergeBranch(branchName)
+		releaseInfo, previous, err := splitLatestRelease(history)
 		if err != nil {
 			return err
 		}
 
-		if environment.CreateGitRelease() {
-			if err := g.CreateRelease(*releaseInfo); err != nil {
+		commitHash, err := g.MergeBranch(branchName, *releaseInfo, previous)
+
+		cliDownloaded := false
+
+		for attempt := 1; attempt <= cfg.MergeRetries; attempt++ {
+			conflict, ok := git.IsMergeConflict(err)
+			if !ok {
+				break
+			}
+
+			logging.Info("%s, regenerating on top of the latest %s (attempt %d of %d)", conflict, conflict.Base, attempt, cfg.MergeRetries)
+
+			if !cliDownloaded {
+				if err := cli.Download(cfg, g); err != nil {
+					return err
+				}
+				cliDownloaded = true
+			}
+
+			var regenerated bool
+			branchName, regenerated, err = regenerateOnLatestBase(cfg, g, branchName)
+			if err != nil {
+				return err
+			}
+
+			if !regenerated {
+				logging.Info("The SDKs are already up to date on %s, nothing to merge", conflict.Base)
+
+				success = true
+				return nil
+			}
+
+			history, err = getReleases(cfg)
+			if err != nil {
+				return err
+			}
+
+			releaseInfo, previous, err = splitLatestRelease(history)
+			if err != nil {
+				return err
+			}
+
+			commitHash, err = g.MergeBranch(branchName, *releaseInfo, previous)
+		}
+
+		if conflict, ok := git.IsMergeConflict(err); ok && cfg.MergeConflictFallback == environment.MergeConflictFallbackPR {
+			logging.Info("%s, opening a PR instead", conflict)
+
+			if !cliDownloaded {
+				if err := cli.Download(cfg, g); err != nil {
+					return err
+				}
+			}
+
+			changeReport, err := openapi.ParseReport(cfg.OpenAPIChangeReport)
+			if err != nil {
+				return err
+			}
+
+			pr, err := g.CreateMergeConflictPR(branchName, *releaseInfo, previous, cfg.PreviousGenVersion, changeReport, conflict)
+			if err != nil {
 				return err
 			}
+			keepBranch = true
+
+			if err := setOutputs(cfg, map[string]string{"pr_url": pr.URL}); err != nil {
+				return err
+			}
+
+			success = true
+			return nil
+		}
+		if err != nil {
+			return err
 		}
 
 		outputs := map[string]string{
 			"commit_hash": commitHash,
 		}
 
-		if err := setOutputs(outputs); err != nil {
+		var releaseErr error
+		if cfg.CreateGitRelease() {
+			results, err := g.CreateRelease(*releaseInfo, previous)
+			if err != nil {
+				return err
+			}
+
+			outputs["release_results"], releaseErr = releaseOutputs(results)
+		}
+
+		if err := setOutputs(cfg, outputs); err != nil {
 			return err
 		}
+		if releaseErr != nil {
+			return releaseErr
+		}
 	}
 
 	success = true
@@ -85,16 +227,45 @@ func Finalize() error {
 	return nil
 }
 
-func getReleasesInfo() (*releases.ReleasesInfo, error) {
-	releasesDir, err := getReleasesDir()
+// regenerateOnLatestBase regenerates the SDKs on a new branch created from the latest commit of the base branch, replacing the branch
+// that couldn't be merged. Returns the name of the branch to clean up and whether any SDKs were regenerated.
+func regenerateOnLatestBase(cfg *environment.Config, g *git.Git, branchName string) (string, bool, error) {
+	if err := g.CheckoutLatestBase(); err != nil {
+		return branchName, false, err
+	}
+
+	newBranchName, err := g.FindOrCreateBranch("")
 	if err != nil {
-		return nil, err
+		return branchName, false, err
 	}
 
-	releasesInfo, err := releases.GetLastReleaseInfo(releasesDir)
+	if err := g.DeleteBranch(branchName); err != nil {
+		logging.Debug("failed to delete branch %s: %v", branchName, err)
+	}
+
+	_, regenerated, err := generateAndCommit(cfg, g)
+	if err != nil {
+		return newBranchName, false, err
+	}
+
+	return newBranchName, regenerated, nil
+}
+
+func getReleases(cfg *environment.Config) (releases.Releases, error) {
+	releasesDir, err := getReleasesDir(cfg)
 	if err != nil {
 		return nil, err
 	}
 
-	return releasesInfo, nil
+	return releases.GetReleases(releasesDir)
+}
+
+// splitLatestRelease returns the latest release and the release history before it
+func splitLatestRelease(history releases.Releases) (*releases.ReleasesInfo, releases.Releases, error) {
+	latest, ok := history.Latest()
+	if !ok {
+		return nil, nil, fmt.Errorf("no releases found")
+	}
+
+	return latest, history[:len(history)-1], nil
 }
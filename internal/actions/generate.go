@@ -6,21 +6,37 @@ import (
 	"github.com/speakeasy-api/sdk-generation-action/internal/cli"
 	"github.com/speakeasy-api/sdk-generation-action/internal/environment"
 	"github.com/speakeasy-api/sdk-generation-action/internal/generate"
+	"github.com/speakeasy-api/sdk-generation-action/internal/git"
 	"github.com/speakeasy-api/sdk-generation-action/internal/logging"
 	"github.com/speakeasy-api/sdk-generation-action/pkg/releases"
 )
 
-func Generate() error {
-	g, err := initAction()
+func Generate(cfg *environment.Config) error {
+	g, err := initAction(cfg)
 	if err != nil {
 		return err
 	}
+	defer g.PrintPlan()
 
-	if err := cli.Download(environment.GetPinnedSpeakeasyVersion(), g); err != nil {
+	// The lock is held until the finalize action merges the regenerated SDKs or opens a PR for them
+	holdLock := false
+	if cfg.ConcurrencyLock {
+		if err := g.AcquireLock(); err != nil {
+			return err
+		}
+
+		defer func() {
+			if !holdLock {
+				releaseLock(g)
+			}
+		}()
+	}
+
+	if err := cli.Download(cfg, g); err != nil {
 		return err
 	}
 
-	mode := environment.GetMode()
+	mode := cfg.Mode
 
 	branchName := ""
 
@@ -38,69 +54,111 @@ func Generate() error {
 	}
 	success := false
 	defer func() {
-		if !success && !environment.IsDebugMode() {
+		if !success && !cfg.Debug {
 			if err := g.DeleteBranch(branchName); err != nil {
 				logging.Debug("failed to delete branch %s: %v", branchName, err)
 			}
 		}
 	}()
 
-	genInfo, outputs, err := generate.Generate(g)
+	outputs, regenerated, err := generateAndCommit(cfg, g)
 	if err != nil {
 		return err
 	}
 
-	if genInfo != nil {
-		docVersion := genInfo.OpenAPIDocVersion
-		speakeasyVersion := genInfo.SpeakeasyVersion
+	resolvedVersion, err := cli.GetSpeakeasyVersion()
+	if err != nil {
+		return err
+	}
+	outputs["resolved_speakeasy_version"] = resolvedVersion.String()
 
-		releaseInfo := releases.ReleasesInfo{
-			ReleaseTitle:      environment.GetInvokeTime().Format("2006-01-02 15:04:05"),
-			DocVersion:        docVersion,
-			SpeakeasyVersion:  speakeasyVersion,
-			GenerationVersion: genInfo.GenerationVersion,
-			DocLocation:       environment.GetOpenAPIDocLocation(),
-			Languages:         map[string]releases.LanguageReleaseInfo{},
-		}
+	outputs["branch_name"] = branchName
 
-		supportedLanguages, err := cli.GetSupportedLanguages()
-		if err != nil {
-			return err
-		}
+	if err := setOutputs(cfg, outputs); err != nil {
+		return err
+	}
+
+	success = true
+	// The finalize action only runs when SDKs were regenerated
+	holdLock = regenerated
+
+	return nil
+}
+
+// generateAndCommit generates the SDKs on the checked out branch and if any were regenerated commits and pushes them along with the updated release history,
+// returning the outputs of the generation and whether any SDKs were regenerated
+func generateAndCommit(cfg *environment.Config, g *git.Git) (map[string]string, bool, error) {
+	genInfo, outputs, err := generate.Generate(cfg, g)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if genInfo == nil {
+		return outputs, false, nil
+	}
 
-		for _, lang := range supportedLanguages {
-			langGenInfo, ok := genInfo.Languages[lang]
+	releaseInfo := releases.ReleasesInfo{
+		ReleaseTitle:      environment.GetInvokeTime().Format(releases.ReleaseTitleFormat),
+		DocVersion:        genInfo.OpenAPIDocVersion,
+		SpeakeasyVersion:  genInfo.SpeakeasyVersion,
+		GenerationVersion: genInfo.GenerationVersion,
+		DocLocation:       cfg.OpenAPIDocLocation,
+		Languages:         map[string]releases.LanguageReleaseInfo{},
+	}
+
+	supportedLanguages, err := cli.GetSupportedLanguages()
+	if err != nil {
+		return nil, false, err
+	}
 
-			if ok && outputs[fmt.Sprintf("%s_regenerated", lang)] == "true" && environment.IsLanguagePublished(lang) {
-				releaseInfo.Languages[lang] = releases.LanguageReleaseInfo{
-					PackageName: langGenInfo.PackageName,
-					Version:     langGenInfo.Version,
-					Path:        outputs[fmt.Sprintf("%s_directory", lang)],
-				}
+	// The commit describes every regenerated language while only published languages are released
+	commitInfo := releaseInfo
+	commitInfo.Languages = map[string]releases.LanguageReleaseInfo{}
+
+	for _, lang := range supportedLanguages {
+		langGenInfo, ok := genInfo.Languages[lang]
+
+		if ok && outputs[fmt.Sprintf("%s_regenerated", lang)] == "true" {
+			langInfo := releases.LanguageReleaseInfo{
+				PackageName:     langGenInfo.PackageName,
+				Version:         langGenInfo.Version,
+				Path:            outputs[fmt.Sprintf("%s_directory", lang)],
+				PreviousVersion: langGenInfo.PreviousVersion,
+				BumpReasons:     langGenInfo.BumpReasons,
 			}
-		}
 
-		releasesDir, err := getReleasesDir()
-		if err != nil {
-			return err
+			commitInfo.Languages[lang] = langInfo
+			if cfg.IsLanguagePublished(lang) {
+				releaseInfo.Languages[lang] = langInfo
+			}
 		}
+	}
 
-		if err := releases.UpdateReleasesFile(releaseInfo, releasesDir); err != nil {
-			return err
-		}
+	releasesDir, err := getReleasesDir(cfg)
+	if err != nil {
+		return nil, false, err
+	}
 
-		if _, err := g.CommitAndPush(docVersion, speakeasyVersion); err != nil {
-			return err
-		}
+	tmpls, err := g.Templates()
+	if err != nil {
+		return nil, false, err
 	}
 
-	outputs["branch_name"] = branchName
+	if err := releases.UpdateReleasesFileWithRenderer(releaseInfo, releasesDir, tmpls.ReleasesRenderer(cfg)); err != nil {
+		return nil, false, err
+	}
 
-	if err := setOutputs(outputs); err != nil {
-		return err
+	history, err := releases.GetReleases(releasesDir)
+	if err != nil {
+		return nil, false, err
 	}
 
-	success = true
+	if _, err := g.CommitAndPush(commitInfo, history[:len(history)-1]); err != nil {
+		return nil, false, err
+	}
 
-	return nil
+	// Unpublished languages aren't in the release history, so the bump is taken from every regenerated language for the finalize action
+	outputs["version_bump"] = commitInfo.Bump().String()
+
+	return outputs, true, nil
 }
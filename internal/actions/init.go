@@ -1,22 +1,26 @@
 package actions
 
 import (
-	"errors"
-
 	"github.com/speakeasy-api/sdk-generation-action/internal/environment"
 	"github.com/speakeasy-api/sdk-generation-action/internal/git"
+	"github.com/speakeasy-api/sdk-generation-action/internal/logging"
 )
 
-func initAction() (*git.Git, error) {
-	accessToken := environment.GetAccessToken()
-	if accessToken == "" {
-		return nil, errors.New("github access token is required")
+func initAction(cfg *environment.Config) (*git.Git, error) {
+	g, err := git.New(cfg)
+	if err != nil {
+		return nil, err
 	}
 
-	g := git.New(accessToken)
 	if err := g.CloneRepo(); err != nil {
 		return nil, err
 	}
 
 	return g, nil
 }
+
+func releaseLock(g *git.Git) {
+	if err := g.ReleaseLock(); err != nil {
+		logging.Info("failed to release lock: %v", err)
+	}
+}
@@ -3,14 +3,16 @@ package actions
 import (
 	"fmt"
 	"os"
+	"sort"
 
+	"github.com/speakeasy-api/sdk-generation-action/internal/environment"
 	"github.com/speakeasy-api/sdk-generation-action/internal/logging"
 )
 
-func setOutputs(outputs map[string]string) error {
+func setOutputs(cfg *environment.Config, outputs map[string]string) error {
 	logging.Info("Setting outputs:")
 
-	outputFile := os.Getenv("GITHUB_OUTPUT")
+	outputFile := cfg.OutputPath
 
 	f, err := os.OpenFile(outputFile, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0o600)
 	if err != nil {
@@ -18,8 +20,14 @@ func setOutputs(outputs map[string]string) error {
 	}
 	defer f.Close()
 
-	for k, v := range outputs {
-		out := fmt.Sprintf("%s=%s\n", k, v)
+	keys := make([]string, 0, len(outputs))
+	for k := range outputs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		out := fmt.Sprintf("%s=%s\n", k, outputs[k])
 		fmt.Print(out)
 
 		if _, err := f.WriteString(out); err != nil {
@@ -1,33 +1,30 @@
 package actions
 
 import (
-	"errors"
 	"fmt"
+	"path"
 	"path/filepath"
 	"strings"
 
 	"github.com/speakeasy-api/sdk-generation-action/internal/environment"
+	"github.com/speakeasy-api/sdk-generation-action/internal/git"
 	"github.com/speakeasy-api/sdk-generation-action/internal/logging"
 	"github.com/speakeasy-api/sdk-generation-action/pkg/releases"
 )
 
-func Release() error {
-	accessToken := environment.GetAccessToken()
-	if accessToken == "" {
-		return errors.New("github access token is required")
-	}
-
-	g, err := initAction()
+func Release(cfg *environment.Config) error {
+	g, err := initAction(cfg)
 	if err != nil {
 		return err
 	}
+	defer g.PrintPlan()
 
 	files, err := g.GetCommitedFiles()
 	if err != nil {
 		fmt.Printf("Failed to get commited files: %s\n", err.Error())
 	}
 
-	if environment.IsDebugMode() {
+	if cfg.Debug {
 		for _, file := range files {
 			logging.Debug("Found commited file: %s", file)
 		}
@@ -43,27 +40,145 @@ func Release() error {
 		}
 	}
 
-	latestRelease, err := releases.GetLastReleaseInfo(dir)
+	newReleases, previous, err := getNewReleases(g, dir)
 	if err != nil {
 		return err
 	}
 
-	if environment.CreateGitRelease() {
-		if err := g.CreateRelease(*latestRelease); err != nil {
-			return err
+	outputs := map[string]string{}
+
+	var releaseErr error
+	if cfg.CreateGitRelease() {
+		results := git.ReleaseResults{}
+		for _, release := range newReleases {
+			releaseResults, err := g.CreateRelease(release, previous)
+			if err != nil {
+				return err
+			}
+			results = append(results, releaseResults...)
 		}
-	}
 
-	outputs := map[string]string{}
+		outputs["release_results"], releaseErr = releaseOutputs(results)
+	}
 
-	for lang, info := range latestRelease.Languages {
-		outputs[fmt.Sprintf("%s_regenerated", lang)] = "true"
-		outputs[fmt.Sprintf("%s_directory", lang)] = info.Path
+	for _, release := range newReleases {
+		for lang, info := range release.Languages {
+			outputs[fmt.Sprintf("%s_regenerated", lang)] = "true"
+			outputs[fmt.Sprintf("%s_directory", lang)] = info.Path
+		}
 	}
 
-	if err := setOutputs(outputs); err != nil {
+	if err := setOutputs(cfg, outputs); err != nil {
 		return err
 	}
 
-	return nil
+	return releaseErr
+}
+
+// releaseOutputs returns the release_results output and an error describing any failed releases, to be returned once the outputs are set
+func releaseOutputs(results git.ReleaseResults) (string, error) {
+	out, err := results.JSON()
+	if err != nil {
+		return "", err
+	}
+
+	failed := results.Failed()
+	if len(failed) == 0 {
+		return out, nil
+	}
+
+	errs := []string{}
+	for _, result := range failed {
+		errs = append(errs, fmt.Sprintf("%s (%s): %s", result.Language, result.TagName, result.Error))
+	}
+
+	return out, fmt.Errorf("failed to create %d of %d releases:\n  %s", len(failed), len(results), strings.Join(errs, "\n  "))
+}
+
+// getNewReleases returns the releases added by the push that triggered the workflow, which can contain multiple generations if for example multiple PRs were merged,
+// along with the releases prior to the push
+func getNewReleases(g *git.Git, dir string) (releases.Releases, releases.Releases, error) {
+	allReleases, err := releases.GetReleases(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	latestRelease, ok := allReleases.Latest()
+	if !ok {
+		return nil, nil, fmt.Errorf("no releases found in %s", dir)
+	}
+
+	previousCount, err := getPreviousReleaseCount(g, dir)
+	if err != nil {
+		logging.Info("Failed to get releases prior to push, only the latest release will be released: %s", err.Error())
+		return releases.Releases{*latestRelease}, allReleases[:len(allReleases)-1], nil
+	}
+
+	if previousCount >= len(allReleases) {
+		return releases.Releases{*latestRelease}, allReleases[:len(allReleases)-1], nil
+	}
+
+	newReleases := allReleases[previousCount:]
+
+	logging.Info("Found %d new releases", len(newReleases))
+
+	// Only the latest version of each language is released if the language was released multiple times
+	latest := releases.Releases{}
+	for i, release := range newReleases {
+		langs := map[string]releases.LanguageReleaseInfo{}
+
+		for lang, info := range release.Languages {
+			superseded := false
+			for _, later := range newReleases[i+1:] {
+				if _, ok := later.Languages[lang]; ok {
+					superseded = true
+					break
+				}
+			}
+
+			if !superseded {
+				langs[lang] = info
+			}
+		}
+
+		if len(langs) > 0 {
+			release.Languages = langs
+			latest = append(latest, release)
+		}
+	}
+
+	return latest, allReleases[:previousCount], nil
+}
+
+func getPreviousReleaseCount(g *git.Git, dir string) (int, error) {
+	ledgerData, err := g.GetFileBeforePush(path.Join(dir, releases.LedgerPath))
+	if err != nil {
+		return 0, err
+	}
+
+	if ledgerData != "" {
+		ledger, err := releases.ParseLedger([]byte(ledgerData))
+		if err != nil {
+			return 0, err
+		}
+
+		return len(ledger.Releases), nil
+	}
+
+	releasesData, err := g.GetFileBeforePush(path.Join(dir, "RELEASES.md"))
+	if err != nil {
+		return 0, err
+	}
+
+	// There were no releases before the push
+	if releasesData == "" {
+		return 0, nil
+	}
+
+	previousReleases, err := releases.ParseAllReleases(releasesData)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse RELEASES.md prior to push: %w", err)
+	}
+
+	return len(previousReleases), nil
 }
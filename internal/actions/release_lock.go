@@ -0,0 +1,23 @@
+package actions
+
+import (
+	"github.com/speakeasy-api/sdk-generation-action/internal/environment"
+	"github.com/speakeasy-api/sdk-generation-action/internal/logging"
+)
+
+// ReleaseLock releases the concurrency lock held by the workflow run, for use when a job between generate and finalize
+// failed or was cancelled so finalize didn't run to release it
+func ReleaseLock(cfg *environment.Config) error {
+	if !cfg.ConcurrencyLock {
+		logging.Info("concurrency_lock is not set, no lock to release")
+		return nil
+	}
+
+	g, err := initAction(cfg)
+	if err != nil {
+		return err
+	}
+	defer g.PrintPlan()
+
+	return g.ReleaseLock()
+}
@@ -2,11 +2,12 @@ package actions
 
 import (
 	"github.com/speakeasy-api/sdk-generation-action/internal/configuration"
+	"github.com/speakeasy-api/sdk-generation-action/internal/environment"
 )
 
-func getReleasesDir() (string, error) {
+func getReleasesDir(cfg *environment.Config) (string, error) {
 	// Find releases file
-	langs, err := configuration.GetAndValidateLanguages(false)
+	langs, err := configuration.GetAndValidateLanguages(cfg.Languages, false)
 	if err != nil {
 		return "", err
 	}
@@ -0,0 +1,33 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Maps GOOS and GOARCH to the naming used for Speakeasy CLI release assets
+var (
+	assetOS = map[string]string{
+		"linux":  "Linux",
+		"darwin": "Darwin",
+	}
+	assetArch = map[string]string{
+		"amd64": "x86_64",
+		"arm64": "arm64",
+		"386":   "i386",
+	}
+)
+
+func getAssetName(version, goos, goarch string) (string, error) {
+	os, ok := assetOS[goos]
+	if !ok {
+		return "", fmt.Errorf("unsupported operating system for the speakeasy cli: %s, use the speakeasy_cli_asset input to select a release asset, URL or path", goos)
+	}
+
+	arch, ok := assetArch[goarch]
+	if !ok {
+		return "", fmt.Errorf("unsupported architecture for the speakeasy cli: %s, use the speakeasy_cli_asset input to select a release asset, URL or path", goarch)
+	}
+
+	return fmt.Sprintf("speakeasy_%s_%s_%s.tar.gz", strings.TrimPrefix(version, "v"), os, arch), nil
+}
@@ -0,0 +1,61 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetAssetName_Success(t *testing.T) {
+	tests := []struct {
+		goos   string
+		goarch string
+		want   string
+	}{
+		{goos: "linux", goarch: "amd64", want: "speakeasy_1.20.0_Linux_x86_64.tar.gz"},
+		{goos: "linux", goarch: "arm64", want: "speakeasy_1.20.0_Linux_arm64.tar.gz"},
+		{goos: "darwin", goarch: "arm64", want: "speakeasy_1.20.0_Darwin_arm64.tar.gz"},
+		{goos: "linux", goarch: "386", want: "speakeasy_1.20.0_Linux_i386.tar.gz"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.goos+"/"+tt.goarch, func(t *testing.T) {
+			got, err := getAssetName("v1.20.0", tt.goos, tt.goarch)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestGetAssetName_Unsupported_Error(t *testing.T) {
+	_, err := getAssetName("v1.20.0", "plan9", "amd64")
+	assert.ErrorContains(t, err, "unsupported operating system for the speakeasy cli: plan9")
+
+	_, err = getAssetName("v1.20.0", "linux", "riscv64")
+	assert.ErrorContains(t, err, "unsupported architecture for the speakeasy cli: riscv64")
+}
+
+func TestDownloadRelease_MissingAsset_ListsAvailable(t *testing.T) {
+	g, _ := newFakeRelease(t, []byte("speakeasy cli"), "")
+
+	_, err := downloadRelease(newCache(""), g, testVersion, "speakeasy_1.20.0_Linux_riscv64.tar.gz")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "has no asset speakeasy_1.20.0_Linux_riscv64.tar.gz")
+	assert.Contains(t, err.Error(), "available assets: "+testAssetName)
+	assert.NotContains(t, err.Error(), testChecksums)
+}
+
+func TestInstall_Binary_Success(t *testing.T) {
+	binDir := t.TempDir()
+
+	src := filepath.Join(t.TempDir(), "speakeasy-custom")
+	require.NoError(t, os.WriteFile(src, []byte("#!/bin/sh\n"), 0o600))
+
+	require.NoError(t, install(src, "speakeasy-custom", binDir))
+
+	info, err := os.Stat(filepath.Join(binDir, "speakeasy"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o755), info.Mode().Perm())
+}
@@ -0,0 +1,152 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// cache is a content addressed store of Speakeasy CLI release assets, assets are stored by their sha256 checksum and looked up by version and asset name
+type cache struct {
+	dir string
+}
+
+func newCache(dir string) *cache {
+	return &cache{dir: dir}
+}
+
+func (c *cache) enabled() bool {
+	return c.dir != ""
+}
+
+func (c *cache) blobPath(checksum string) string {
+	return filepath.Join(c.dir, "sha256", checksum)
+}
+
+func (c *cache) indexPath(version, assetName string) string {
+	return filepath.Join(c.dir, version, assetName+".sha256")
+}
+
+// get returns the path of the cached asset or an empty string if the asset isn't cached, cached assets are verified before being returned
+func (c *cache) get(version, assetName string) (string, error) {
+	if !c.enabled() {
+		return "", nil
+	}
+
+	data, err := os.ReadFile(c.indexPath(version, assetName))
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read speakeasy cli cache: %w", err)
+	}
+
+	checksum := strings.TrimSpace(string(data))
+	blob := c.blobPath(checksum)
+
+	if err := verifyChecksum(blob, checksum); err != nil {
+		fmt.Printf("Ignoring invalid cached speakeasy cli %s: %s\n", blob, err.Error())
+		return "", nil
+	}
+
+	return blob, nil
+}
+
+// put moves the verified asset at fileName into the cache and returns its new path, when caching is disabled fileName is returned as is
+func (c *cache) put(version, assetName, checksum, fileName string) (string, error) {
+	if !c.enabled() {
+		return fileName, nil
+	}
+
+	blob := c.blobPath(checksum)
+	index := c.indexPath(version, assetName)
+
+	for _, dir := range []string{filepath.Dir(blob), filepath.Dir(index)} {
+		if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+			return "", fmt.Errorf("failed to create speakeasy cli cache directory: %w", err)
+		}
+	}
+
+	if err := moveFile(fileName, blob); err != nil {
+		return "", fmt.Errorf("failed to add speakeasy cli to cache: %w", err)
+	}
+
+	if err := os.WriteFile(index, []byte(checksum+"\n"), 0o644); err != nil {
+		return "", fmt.Errorf("failed to add speakeasy cli to cache: %w", err)
+	}
+
+	return blob, nil
+}
+
+// findChecksum finds the sha256 checksum of assetName in a checksums file in the format output by sha256sum
+func findChecksum(checksums []byte, assetName string) (string, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(checksums))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+
+		if strings.TrimPrefix(fields[1], "*") == assetName {
+			return strings.ToLower(fields[0]), nil
+		}
+	}
+
+	return "", fmt.Errorf("no checksum found for %s", assetName)
+}
+
+func verifyChecksum(fileName, expected string) error {
+	f, err := os.Open(fileName)
+	if err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+
+	actual := hex.EncodeToString(h.Sum(nil))
+	if actual != expected {
+		return fmt.Errorf("checksum mismatch: expected %s, got %s", expected, actual)
+	}
+
+	return nil
+}
+
+func moveFile(src, dst string) error {
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+
+	// Renaming fails across devices, for example when the cache is on a mounted volume
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+
+	if err := out.Close(); err != nil {
+		return err
+	}
+
+	return os.Remove(src)
+}
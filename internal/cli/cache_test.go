@@ -0,0 +1,138 @@
+package cli
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testVersion   = "v1.20.0"
+	testAssetName = "speakeasy_1.20.0_Linux_x86_64.tar.gz"
+	testChecksums = "speakeasy_1.20.0_checksums.txt"
+)
+
+type fakeReleaseGit struct {
+	assets map[string]string
+}
+
+func (f *fakeReleaseGit) GetSpeakeasyReleases() ([]Release, error) {
+	return []Release{{Tag: testVersion}}, nil
+}
+
+func (f *fakeReleaseGit) GetLatestSpeakeasyRelease() (string, error) {
+	return testVersion, nil
+}
+
+func (f *fakeReleaseGit) GetReleaseAssets(tag string) (map[string]string, error) {
+	return f.assets, nil
+}
+
+func newFakeRelease(t *testing.T, asset []byte, checksums string) (*fakeReleaseGit, *int) {
+	t.Helper()
+
+	downloads := 0
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/" + testAssetName:
+			downloads++
+			_, _ = w.Write(asset)
+		case "/" + testChecksums:
+			_, _ = w.Write([]byte(checksums))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return &fakeReleaseGit{
+		assets: map[string]string{
+			testAssetName: server.URL + "/" + testAssetName,
+			testChecksums: server.URL + "/" + testChecksums,
+		},
+	}, &downloads
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func TestDownloadRelease_Cached_Success(t *testing.T) {
+	asset := []byte("speakeasy cli")
+	checksums := fmt.Sprintf("%s  speakeasy_1.20.0_Darwin_arm64.tar.gz\n%s  %s\n", sha256Hex([]byte("other")), sha256Hex(asset), testAssetName)
+
+	g, downloads := newFakeRelease(t, asset, checksums)
+	c := newCache(t.TempDir())
+
+	cached, err := c.get(testVersion, testAssetName)
+	require.NoError(t, err)
+	assert.Empty(t, cached)
+
+	fileName, err := downloadRelease(c, g, testVersion, testAssetName)
+	require.NoError(t, err)
+	assert.Equal(t, c.blobPath(sha256Hex(asset)), fileName)
+
+	data, err := os.ReadFile(fileName)
+	require.NoError(t, err)
+	assert.Equal(t, asset, data)
+
+	cached, err = c.get(testVersion, testAssetName)
+	require.NoError(t, err)
+	assert.Equal(t, fileName, cached)
+	assert.Equal(t, 1, *downloads)
+}
+
+func TestDownloadRelease_ChecksumMismatch_Error(t *testing.T) {
+	checksums := fmt.Sprintf("%s  %s\n", sha256Hex([]byte("expected")), testAssetName)
+
+	g, _ := newFakeRelease(t, []byte("tampered"), checksums)
+	c := newCache(t.TempDir())
+
+	_, err := downloadRelease(c, g, testVersion, testAssetName)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "checksum mismatch")
+
+	cached, err := c.get(testVersion, testAssetName)
+	require.NoError(t, err)
+	assert.Empty(t, cached)
+}
+
+func TestDownloadRelease_MissingChecksum_Error(t *testing.T) {
+	g, _ := newFakeRelease(t, []byte("speakeasy cli"), "")
+
+	_, err := downloadRelease(newCache(""), g, testVersion, testAssetName)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no checksum found for "+testAssetName)
+}
+
+func TestCache_CorruptedBlob_Ignored(t *testing.T) {
+	asset := []byte("speakeasy cli")
+	checksums := fmt.Sprintf("%s  %s\n", sha256Hex(asset), testAssetName)
+
+	g, downloads := newFakeRelease(t, asset, checksums)
+	c := newCache(t.TempDir())
+
+	fileName, err := downloadRelease(c, g, testVersion, testAssetName)
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(fileName, []byte("corrupted"), 0o644))
+
+	cached, err := c.get(testVersion, testAssetName)
+	require.NoError(t, err)
+	assert.Empty(t, cached)
+
+	fileName, err = downloadRelease(c, g, testVersion, testAssetName)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(c.dir, "sha256", sha256Hex(asset)), fileName)
+	assert.Equal(t, 2, *downloads)
+}
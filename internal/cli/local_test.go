@@ -0,0 +1,76 @@
+package cli
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/speakeasy-api/sdk-generation-action/internal/environment"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type unreachableGit struct{}
+
+func (unreachableGit) GetSpeakeasyReleases() ([]Release, error) {
+	return nil, errors.New("unexpected call to GetSpeakeasyReleases")
+}
+
+func (unreachableGit) GetLatestSpeakeasyRelease() (string, error) {
+	return "", errors.New("unexpected call to GetLatestSpeakeasyRelease")
+}
+
+func (unreachableGit) GetReleaseAssets(tag string) (map[string]string, error) {
+	return nil, errors.New("unexpected call to GetReleaseAssets")
+}
+
+func writeStubCLI(t *testing.T, dir, cliVersion string) string {
+	t.Helper()
+
+	cliPath := filepath.Join(dir, "speakeasy")
+	require.NoError(t, os.WriteFile(cliPath, []byte("#!/bin/sh\necho \"speakeasy version "+cliVersion+"\"\n"), 0o755))
+
+	t.Cleanup(func() { speakeasyPath = "" })
+
+	return cliPath
+}
+
+func TestDownload_LocalPath_Success(t *testing.T) {
+	cliPath := writeStubCLI(t, t.TempDir(), "1.20.0")
+
+	err := Download(&environment.Config{SpeakeasyPath: cliPath, SpeakeasyVersion: "v1.20.0"}, unreachableGit{})
+	require.NoError(t, err)
+	assert.Equal(t, cliPath, speakeasyPath)
+
+	v, err := GetSpeakeasyVersion()
+	require.NoError(t, err)
+	assert.Equal(t, "1.20.0", v.String())
+}
+
+func TestDownload_LocalOnPath_Success(t *testing.T) {
+	dir := t.TempDir()
+	cliPath := writeStubCLI(t, dir, "1.20.0")
+	t.Setenv("PATH", dir)
+
+	err := Download(&environment.Config{SpeakeasyPath: "speakeasy", SpeakeasyVersion: "latest"}, unreachableGit{})
+	require.NoError(t, err)
+	assert.Equal(t, cliPath, speakeasyPath)
+}
+
+func TestDownload_LocalVersionMismatch_Error(t *testing.T) {
+	cliPath := writeStubCLI(t, t.TempDir(), "1.19.0")
+
+	err := Download(&environment.Config{SpeakeasyPath: cliPath, SpeakeasyVersion: "1.20.0"}, unreachableGit{})
+	assert.ErrorContains(t, err, "is version 1.19.0 but speakeasy_version is pinned to 1.20.0")
+}
+
+func TestDownload_LocalMissing_Error(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+
+	err := Download(&environment.Config{SpeakeasyPath: "speakeasy"}, unreachableGit{})
+	assert.ErrorContains(t, err, "failed to find speakeasy cli speakeasy on PATH")
+
+	err = Download(&environment.Config{SpeakeasyPath: filepath.Join(t.TempDir(), "speakeasy")}, unreachableGit{})
+	assert.ErrorContains(t, err, "failed to find speakeasy cli at")
+}
@@ -0,0 +1,203 @@
+package cli
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/go-version"
+)
+
+// Release is a release of the Speakeasy CLI
+type Release struct {
+	Tag        string
+	Prerelease bool
+	Draft      bool
+}
+
+// parseVersionRequirement parses the speakeasy_version input which is either "latest", an exact version or a set of semver constraints.
+// An exact version is returned as a version, "latest" returns neither a version nor constraints.
+func parseVersionRequirement(requirement string) (*version.Version, version.Constraints, error) {
+	requirement = strings.TrimSpace(requirement)
+
+	if requirement == "" || requirement == "latest" {
+		return nil, nil, nil
+	}
+
+	if v, err := version.NewVersion(requirement); err == nil {
+		return v, nil, nil
+	}
+
+	constraints, err := version.NewConstraint(normalizeConstraints(requirement))
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid speakeasy_version %s, expected latest, a version or version constraints: %w", requirement, err)
+	}
+
+	return nil, constraints, nil
+}
+
+// normalizeConstraints converts constraints to the syntax supported by go-version, allowing them to be separated by spaces as well as commas
+// (for example ">=1.38 <2") and supporting the npm style tilde and caret ranges (for example "~1.40" and "^1.38")
+func normalizeConstraints(requirement string) string {
+	fields := strings.Fields(strings.ReplaceAll(requirement, ",", " "))
+
+	constraints := []string{}
+	operator := ""
+
+	for _, field := range fields {
+		// Operators separated from their version by a space are joined back together
+		if strings.Trim(field, "=<>!~^") == "" {
+			operator += field
+			continue
+		}
+
+		constraints = append(constraints, expandRange(operator+field)...)
+		operator = ""
+	}
+
+	if operator != "" {
+		constraints = append(constraints, operator)
+	}
+
+	return strings.Join(constraints, ", ")
+}
+
+// expandRange expands tilde and caret ranges into a lower and upper bound, other constraints are returned as is
+func expandRange(constraint string) []string {
+	var op string
+	switch {
+	case strings.HasPrefix(constraint, "~>"):
+		return []string{constraint}
+	case strings.HasPrefix(constraint, "~"), strings.HasPrefix(constraint, "^"):
+		op = constraint[:1]
+	default:
+		return []string{constraint}
+	}
+
+	raw := strings.TrimPrefix(constraint[1:], "v")
+
+	v, err := version.NewVersion(raw)
+	if err != nil {
+		// Left for go-version to report as malformed
+		return []string{constraint}
+	}
+
+	segments := v.Segments()
+	specified := len(strings.Split(strings.SplitN(raw, "-", 2)[0], "."))
+
+	var upper string
+	switch {
+	case op == "~" && specified == 1:
+		// ~1 allows any 1.x.x
+		upper = fmt.Sprintf("%d.0.0", segments[0]+1)
+	case op == "~":
+		// ~1.40 and ~1.40.2 allow any 1.40.x
+		upper = fmt.Sprintf("%d.%d.0", segments[0], segments[1]+1)
+	case segments[0] > 0 || specified == 1:
+		// ^1.38 allows any 1.x.x from 1.38.0
+		upper = fmt.Sprintf("%d.0.0", segments[0]+1)
+	case segments[1] > 0 || specified == 2:
+		// ^0.38 allows any 0.38.x
+		upper = fmt.Sprintf("0.%d.0", segments[1]+1)
+	default:
+		// ^0.0.3 only allows 0.0.3
+		upper = fmt.Sprintf("0.0.%d", segments[2]+1)
+	}
+
+	return []string{">= " + v.String(), "< " + upper}
+}
+
+// resolveVersion resolves the speakeasy_version input to the tag of the highest matching release, exact versions are used as is without looking up releases.
+// Releases are only listed when resolving constraints or prereleases, otherwise the latest release is looked up directly.
+func resolveVersion(requirement string, allowPrereleases bool, g Git) (string, error) {
+	exact, constraints, err := parseVersionRequirement(requirement)
+	if err != nil {
+		return "", err
+	}
+
+	if exact != nil {
+		tag := strings.TrimSpace(requirement)
+		if !strings.HasPrefix(tag, "v") {
+			tag = "v" + tag
+		}
+
+		return tag, nil
+	}
+
+	if constraints == nil && !allowPrereleases {
+		return g.GetLatestSpeakeasyRelease()
+	}
+
+	releases, err := g.GetSpeakeasyReleases()
+	if err != nil {
+		return "", err
+	}
+
+	type candidate struct {
+		tag     string
+		version *version.Version
+	}
+
+	candidates := []candidate{}
+
+	for _, release := range releases {
+		if release.Draft {
+			continue
+		}
+
+		v, err := version.NewVersion(release.Tag)
+		if err != nil {
+			continue
+		}
+
+		prerelease := release.Prerelease || v.Prerelease() != ""
+		if prerelease && !allowPrereleases {
+			continue
+		}
+
+		if constraints != nil {
+			// Constraints never match prereleases so they are checked against the release the prerelease is for
+			check := v
+			if prerelease {
+				check = v.Core()
+			}
+
+			if !constraints.Check(check) {
+				continue
+			}
+		}
+
+		candidates = append(candidates, candidate{tag: release.Tag, version: v})
+	}
+
+	if len(candidates) == 0 {
+		if constraints != nil {
+			return "", fmt.Errorf("no speakeasy cli release found matching %s", constraints.String())
+		}
+
+		return "", fmt.Errorf("no speakeasy cli releases found")
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].version.GreaterThan(candidates[j].version)
+	})
+
+	return candidates[0].tag, nil
+}
+
+// checkVersion checks the version of a locally provided cli against the speakeasy_version input
+func checkVersion(localVersion *version.Version, requirement string) error {
+	exact, constraints, err := parseVersionRequirement(requirement)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case exact != nil && !localVersion.Equal(exact):
+		return fmt.Errorf("version %s but speakeasy_version is pinned to %s", localVersion, exact)
+	case constraints != nil && !constraints.Check(localVersion.Core()):
+		return fmt.Errorf("version %s which doesn't match the speakeasy_version constraints %s", localVersion, constraints)
+	}
+
+	return nil
+}
@@ -0,0 +1,109 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/hashicorp/go-version"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type releasesGit struct {
+	unreachableGit
+	releases []Release
+}
+
+func (g releasesGit) GetSpeakeasyReleases() ([]Release, error) {
+	return g.releases, nil
+}
+
+// latestGit only supports looking up the latest release, to check releases aren't listed unless needed
+type latestGit struct {
+	unreachableGit
+	latest string
+}
+
+func (g latestGit) GetLatestSpeakeasyRelease() (string, error) {
+	return g.latest, nil
+}
+
+var testReleases = []Release{
+	{Tag: "v1.38.2"},
+	{Tag: "v1.40.1"},
+	{Tag: "v1.9.0"},
+	{Tag: "v2.1.0"},
+	{Tag: "v1.40.3"},
+	{Tag: "v2.2.0-rc.1", Prerelease: true},
+	{Tag: "v1.41.0"},
+	{Tag: "v3.0.0", Draft: true},
+	{Tag: "nightly"},
+}
+
+func TestResolveVersion_Success(t *testing.T) {
+	tests := []struct {
+		name             string
+		requirement      string
+		allowPrereleases bool
+		want             string
+	}{
+		{name: "latest with prereleases", requirement: "latest", allowPrereleases: true, want: "v2.2.0-rc.1"},
+		{name: "tilde", requirement: "~1.40", want: "v1.40.3"},
+		{name: "tilde patch", requirement: "~1.40.2", want: "v1.40.3"},
+		{name: "tilde major", requirement: "~1", want: "v1.41.0"},
+		{name: "caret", requirement: "^1.38", want: "v1.41.0"},
+		{name: "pessimistic", requirement: "~> 1.40", want: "v1.41.0"},
+		{name: "space separated range", requirement: ">=1.38 <2", want: "v1.41.0"},
+		{name: "comma separated range", requirement: ">= 1.38, < 1.41", want: "v1.40.3"},
+		{name: "range with prereleases", requirement: ">=2", allowPrereleases: true, want: "v2.2.0-rc.1"},
+		{name: "exact", requirement: "1.38.2", want: "v1.38.2"},
+		{name: "exact with prefix", requirement: "v1.38.2", want: "v1.38.2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveVersion(tt.requirement, tt.allowPrereleases, releasesGit{releases: testReleases})
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestResolveVersion_Latest_NoListing(t *testing.T) {
+	for _, requirement := range []string{"latest", "", " latest "} {
+		got, err := resolveVersion(requirement, false, latestGit{latest: "v2.1.0"})
+		require.NoError(t, err)
+		assert.Equal(t, "v2.1.0", got)
+	}
+}
+
+func TestResolveVersion_Exact_NoLookup(t *testing.T) {
+	got, err := resolveVersion("1.2.3", false, unreachableGit{})
+	require.NoError(t, err)
+	assert.Equal(t, "v1.2.3", got)
+}
+
+func TestResolveVersion_Error(t *testing.T) {
+	_, err := resolveVersion(">=4", false, releasesGit{releases: testReleases})
+	assert.ErrorContains(t, err, "no speakeasy cli release found matching >=4")
+
+	_, err = resolveVersion("not a version", false, releasesGit{releases: testReleases})
+	assert.ErrorContains(t, err, "invalid speakeasy_version not a version")
+}
+
+func TestNormalizeConstraints(t *testing.T) {
+	assert.Equal(t, ">=1.38, <2", normalizeConstraints(">=1.38 <2"))
+	assert.Equal(t, ">=1.38, <2", normalizeConstraints(">= 1.38 , < 2"))
+	assert.Equal(t, ">= 1.40.0, < 1.41.0", normalizeConstraints("~1.40"))
+	assert.Equal(t, ">= 0.38.0, < 0.39.0", normalizeConstraints("^0.38"))
+	assert.Equal(t, ">= 0.0.3, < 0.0.4", normalizeConstraints("^0.0.3"))
+	assert.Equal(t, "~>1.40", normalizeConstraints("~> 1.40"))
+}
+
+func TestCheckVersion(t *testing.T) {
+	v := version.Must(version.NewVersion("1.40.2"))
+
+	assert.NoError(t, checkVersion(v, "1.40.2"))
+	assert.NoError(t, checkVersion(v, "~1.40"))
+	assert.NoError(t, checkVersion(v, ">=1.38 <2"))
+	assert.ErrorContains(t, checkVersion(v, "1.40.1"), "pinned to 1.40.1")
+	assert.ErrorContains(t, checkVersion(v, ">=2"), "doesn't match the speakeasy_version constraints >=2")
+}
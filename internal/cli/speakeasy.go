@@ -9,55 +9,234 @@ import (
 	"os/exec"
 	"path"
 	"path/filepath"
+	"runtime"
+	"sort"
 	"strings"
 
 	"github.com/speakeasy-api/sdk-generation-action/internal/download"
 	"github.com/speakeasy-api/sdk-generation-action/internal/environment"
 )
 
+// speakeasyPath is the path of a locally provided speakeasy cli, when empty the downloaded cli is used
+var speakeasyPath string
+
 type Git interface {
-	GetLatestTag() (string, error)
+	GetSpeakeasyReleases() ([]Release, error)
+	// GetLatestSpeakeasyRelease returns the tag of the latest release, which is never a draft or prerelease
+	GetLatestSpeakeasyRelease() (string, error)
+	GetReleaseAssets(tag string) (map[string]string, error)
 }
 
-func Download(pinnedVersion string, g Git) error {
-	version := pinnedVersion
+func Download(cfg *environment.Config, g Git) error {
+	if cfg.SpeakeasyPath != "" {
+		return useLocal(cfg.SpeakeasyPath, cfg.SpeakeasyVersion)
+	}
+
+	baseDir := environment.GetBaseDir()
+	binDir := filepath.Join(baseDir, "bin")
+
+	if isURL(cfg.SpeakeasyCLIAsset) {
+		fmt.Println("Downloading speakeasy cli from: ", cfg.SpeakeasyCLIAsset)
+
+		fileName, err := download.DownloadFile(cfg.SpeakeasyCLIAsset, "speakeasy*", "", "")
+		if err != nil {
+			return fmt.Errorf("failed to download speakeasy cli: %w", err)
+		}
+		defer os.Remove(fileName)
+
+		return install(fileName, path.Base(cfg.SpeakeasyCLIAsset), binDir)
+	}
+
+	if cfg.SpeakeasyCLIAsset != "" {
+		if _, err := os.Stat(cfg.SpeakeasyCLIAsset); err == nil {
+			fmt.Println("Using speakeasy cli from: ", cfg.SpeakeasyCLIAsset)
+
+			return install(cfg.SpeakeasyCLIAsset, filepath.Base(cfg.SpeakeasyCLIAsset), binDir)
+		}
+	}
+
+	version, err := resolveVersion(cfg.SpeakeasyVersion, cfg.SpeakeasyAllowPrereleases, g)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("Downloading speakeasy cli version: ", version)
+
+	assetName := cfg.SpeakeasyCLIAsset
+	if assetName == "" {
+		assetName, err = getAssetName(version, runtime.GOOS, runtime.GOARCH)
+		if err != nil {
+			return err
+		}
+	}
+
+	c := newCache(cfg.SpeakeasyCacheDir)
+
+	fileName, err := c.get(version, assetName)
+	if err != nil {
+		return err
+	}
 
-	if pinnedVersion == "" || pinnedVersion == "latest" {
-		latestVersion, err := g.GetLatestTag()
+	if fileName == "" {
+		fileName, err = downloadRelease(c, g, version, assetName)
 		if err != nil {
 			return err
 		}
-		version = latestVersion
 	} else {
-		if !strings.HasPrefix(pinnedVersion, "v") {
-			version = "v" + pinnedVersion
+		fmt.Println("Using cached speakeasy cli: ", fileName)
+	}
+
+	if !c.enabled() {
+		defer os.Remove(fileName)
+	}
+
+	return install(fileName, assetName, binDir)
+}
+
+// useLocal uses an existing speakeasy cli instead of downloading one, a name without a path separator is looked up on the PATH
+func useLocal(cliPath, requirement string) error {
+	resolved := cliPath
+
+	if !strings.ContainsRune(cliPath, os.PathSeparator) {
+		var err error
+		resolved, err = exec.LookPath(cliPath)
+		if err != nil {
+			return fmt.Errorf("failed to find speakeasy cli %s on PATH: %w", cliPath, err)
+		}
+	} else {
+		var err error
+		resolved, err = filepath.Abs(cliPath)
+		if err != nil {
+			return fmt.Errorf("failed to resolve speakeasy cli path %s: %w", cliPath, err)
+		}
+
+		if _, err := os.Stat(resolved); err != nil {
+			return fmt.Errorf("failed to find speakeasy cli at %s: %w", cliPath, err)
 		}
 	}
 
-	fmt.Println("Downloading speakeasy cli version: ", version)
+	fmt.Println("Using speakeasy cli at: ", resolved)
 
-	speakeasyCLIPath := fmt.Sprintf("https://github.com/speakeasy-api/speakeasy/releases/download/%s/speakeasy_%s_Linux_x86_64.tar.gz", version, strings.TrimPrefix(version, "v"))
+	speakeasyPath = resolved
 
-	fileName, err := download.DownloadFile(speakeasyCLIPath, "speakeasy*.tar.gz", "", "")
+	if requirement == "" || requirement == "latest" {
+		return nil
+	}
+
+	localVersion, err := GetSpeakeasyVersion()
 	if err != nil {
-		return fmt.Errorf("failed to download speakeasy cli: %w", err)
+		return err
 	}
 
-	baseDir := environment.GetBaseDir()
+	if err := checkVersion(localVersion, requirement); err != nil {
+		return fmt.Errorf("speakeasy cli at %s is %w", resolved, err)
+	}
+
+	return nil
+}
+
+// install installs the speakeasy cli into binDir from either a tar.gz archive or a binary
+func install(fileName, name, binDir string) error {
+	if strings.HasSuffix(name, ".tar.gz") || strings.HasSuffix(name, ".tgz") {
+		if err := extract(fileName, binDir); err != nil {
+			return fmt.Errorf("failed to extract speakeasy cli: %w", err)
+		}
+
+		return nil
+	}
+
+	if err := os.MkdirAll(binDir, os.ModePerm); err != nil {
+		return fmt.Errorf("failed to create output directory: %s - %w", binDir, err)
+	}
+
+	in, err := os.Open(fileName)
+	if err != nil {
+		return fmt.Errorf("failed to open speakeasy cli: %w", err)
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(filepath.Join(binDir, "speakeasy"), os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o755)
+	if err != nil {
+		return fmt.Errorf("failed to install speakeasy cli: %w", err)
+	}
+	defer out.Close()
 
-	if err := extract(fileName, filepath.Join(baseDir, "bin")); err != nil {
-		return fmt.Errorf("failed to extract speakeasy cli: %w", err)
+	if _, err := io.Copy(out, in); err != nil {
+		return fmt.Errorf("failed to install speakeasy cli: %w", err)
 	}
 
-	os.Remove(fileName)
+	if err := out.Chmod(0o755); err != nil {
+		return fmt.Errorf("failed to set file permissions: %w", err)
+	}
 
 	return nil
 }
 
-func runSpeakeasyCommand(args ...string) (string, error) {
-	baseDir := environment.GetBaseDir()
+func isURL(s string) bool {
+	return strings.HasPrefix(s, "https://") || strings.HasPrefix(s, "http://")
+}
+
+// downloadRelease downloads the release asset and its published checksums, verifying the asset before adding it to the cache
+func downloadRelease(c *cache, g Git, version, assetName string) (string, error) {
+	assets, err := g.GetReleaseAssets(version)
+	if err != nil {
+		return "", err
+	}
+
+	speakeasyCLIPath, ok := assets[assetName]
+	if !ok {
+		available := []string{}
+		for name := range assets {
+			if !strings.HasSuffix(name, "_checksums.txt") {
+				available = append(available, name)
+			}
+		}
+		sort.Strings(available)
 
-	cmdPath := strings.Join([]string{baseDir, "bin", "speakeasy"}, string(os.PathSeparator))
+		return "", fmt.Errorf("speakeasy cli release %s has no asset %s, use the speakeasy_cli_asset input to select one of the available assets: %s", version, assetName, strings.Join(available, ", "))
+	}
+
+	checksumsName := fmt.Sprintf("speakeasy_%s_checksums.txt", strings.TrimPrefix(version, "v"))
+
+	checksumsPath, ok := assets[checksumsName]
+	if !ok {
+		return "", fmt.Errorf("speakeasy cli release %s has no checksums file %s", version, checksumsName)
+	}
+
+	checksumsFile, err := download.DownloadFile(checksumsPath, "speakeasy*_checksums.txt", "", "")
+	if err != nil {
+		return "", fmt.Errorf("failed to download speakeasy cli checksums: %w", err)
+	}
+	defer os.Remove(checksumsFile)
+
+	checksums, err := os.ReadFile(checksumsFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read speakeasy cli checksums: %w", err)
+	}
+
+	expected, err := findChecksum(checksums, assetName)
+	if err != nil {
+		return "", err
+	}
+
+	fileName, err := download.DownloadFile(speakeasyCLIPath, "speakeasy*.tar.gz", "", "")
+	if err != nil {
+		return "", fmt.Errorf("failed to download speakeasy cli: %w", err)
+	}
+
+	if err := verifyChecksum(fileName, expected); err != nil {
+		os.Remove(fileName)
+		return "", fmt.Errorf("failed to verify speakeasy cli %s: %w", assetName, err)
+	}
+
+	return c.put(version, assetName, expected, fileName)
+}
+
+func runSpeakeasyCommand(args ...string) (string, error) {
+	cmdPath := speakeasyPath
+	if cmdPath == "" {
+		cmdPath = strings.Join([]string{environment.GetBaseDir(), "bin", "speakeasy"}, string(os.PathSeparator))
+	}
 
 	output, err := exec.Command(cmdPath, args...).CombinedOutput()
 	if err != nil {
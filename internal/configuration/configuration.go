@@ -8,18 +8,17 @@ import (
 
 	config "github.com/speakeasy-api/sdk-gen-config"
 	"github.com/speakeasy-api/sdk-generation-action/internal/cli"
-	"github.com/speakeasy-api/sdk-generation-action/internal/environment"
 	"golang.org/x/exp/slices"
 	"gopkg.in/yaml.v3"
 )
 
-type genConfig struct {
+type GenConfig struct {
 	ConfigDir string
 	Config    *config.Config
 }
 
-func LoadGeneratorConfigs(baseDir string, langConfigs map[string]string) (map[string]*genConfig, error) {
-	genConfigs := map[string]*genConfig{}
+func LoadGeneratorConfigs(baseDir string, langConfigs map[string]string) (map[string]*GenConfig, error) {
+	genConfigs := map[string]*GenConfig{}
 
 	sharedCache := map[string]*config.Config{}
 
@@ -43,7 +42,7 @@ func LoadGeneratorConfigs(baseDir string, langConfigs map[string]string) (map[st
 			sharedCache[configDir] = cfg
 		}
 
-		genConfig := genConfig{
+		genConfig := GenConfig{
 			ConfigDir: configDir,
 			Config:    cfg,
 		}
@@ -54,9 +53,7 @@ func LoadGeneratorConfigs(baseDir string, langConfigs map[string]string) (map[st
 	return genConfigs, nil
 }
 
-func GetAndValidateLanguages(checkLangSupported bool) (map[string]string, error) {
-	languages := environment.GetLanguages()
-
+func GetAndValidateLanguages(languages string, checkLangSupported bool) (map[string]string, error) {
 	languages = strings.ReplaceAll(languages, "\\n", "\n")
 
 	langs := []interface{}{}
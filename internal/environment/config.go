@@ -0,0 +1,445 @@
+package environment
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	validModes                   = []Mode{ModeDirect, ModePR}
+	validActions                 = []Action{ActionGenerate, ActionFinalize, ActionRelease, ActionCleanup, ActionReleaseLock}
+	validProviders               = []Provider{ProviderGitHub, ProviderGitea}
+	validMergeMethods            = []MergeMethod{MergeMethodMerge, MergeMethodSquash, MergeMethodRebase}
+	validMergeStrategies         = []MergeStrategy{MergeStrategyFastForward, MergeStrategyMerge, MergeStrategySquash, MergeStrategyRebase}
+	validMergeConflictFallbacks  = []MergeConflictFallback{MergeConflictFallbackFail, MergeConflictFallbackPR}
+	validExistingReleasePolicies = []ExistingReleasePolicy{ExistingReleaseSkip, ExistingReleaseUpdate, ExistingReleaseFail}
+	validVersionBumps            = []string{"none", "patch", "minor", "major"}
+)
+
+// Config is the configuration of the action, loaded from the action inputs and GitHub workflow environment
+type Config struct {
+	Action Action
+	Mode   Mode
+	Force  bool
+	DryRun bool
+	// Debug enables debug logging and keeps the branches of failed runs for inspection, set by the debug input or by re-running
+	// the workflow with debug logging enabled
+	Debug bool
+
+	AccessToken string
+	// AppID, AppInstallationID and AppPrivateKey authenticate as a GitHub App installation instead of using AccessToken
+	AppID             int64
+	AppInstallationID int64
+	AppPrivateKey     string
+
+	Provider       Provider
+	ProviderAPIURL string
+	// APIURL is the GitHub REST API's base URL, for GitHub Enterprise Server it is derived from ServerURL if not set
+	APIURL string
+	// CABundle contains PEM encoded CA certificates to trust in addition to the system's, for servers using an internal CA
+	CABundle string
+
+	SpeakeasyVersion          string
+	SpeakeasyCacheDir         string
+	SpeakeasyCLIAsset         string
+	SpeakeasyPath             string
+	SpeakeasyAllowPrereleases bool
+	OpenAPIDocLocation        string
+	OpenAPIDocAuthHeader      string
+	OpenAPIDocAuthToken       string
+	Languages                 string
+	MaxParallelGenerations    int
+
+	CreateRelease         bool
+	ExistingReleasePolicy ExistingReleasePolicy
+	PublishedLanguages    map[string]bool
+
+	BranchName          string
+	PreviousGenVersion  string
+	OpenAPIChangeReport string
+	// VersionBump is the largest version bump of the SDKs regenerated by the generate action, empty if not provided
+	VersionBump string
+
+	PRLabels        []string
+	PRReviewers     []string
+	PRTeamReviewers []string
+	PRAssignees     []string
+	PRMilestone     string
+	PRDraft         bool
+
+	TemplatesDir        string
+	ConventionalCommits bool
+	PerLanguageCommits  bool
+
+	CommitAuthorName     string
+	CommitAuthorEmail    string
+	CommitterName        string
+	CommitterEmail       string
+	SigningKey           string
+	SigningKeyPassphrase string
+
+	AutoMerge          bool
+	AutoMergeMethod    MergeMethod
+	AutoMergeSkipMajor bool
+
+	MergeStrategy         MergeStrategy
+	MergeRetries          int
+	MergeConflictFallback MergeConflictFallback
+
+	ConcurrencyLock bool
+	LockTimeout     time.Duration
+	LockExpiry      time.Duration
+
+	// CleanupMaxAge is the age after which regeneration branches without an open PR are deleted by the cleanup action
+	CleanupMaxAge time.Duration
+
+	ServerURL       string
+	Repository      string
+	RepositoryOwner string
+	Ref             string
+	WorkflowName    string
+	RunID           string
+	EventPath       string
+	OutputPath      string
+}
+
+const githubServerURL = "https://github.com"
+
+var publishableLanguages = []string{"python", "typescript", "java", "php"}
+
+// Load loads and validates the configuration using getenv to look up environment variables, normally os.Getenv
+func Load(getenv func(string) string) (*Config, error) {
+	var errs []string
+
+	getBool := func(name string) bool {
+		value := getenv(name)
+
+		switch value {
+		case "", "false":
+			return false
+		case "true":
+			return true
+		default:
+			errs = append(errs, fmt.Sprintf("invalid value %q for %s, expected true or false", value, inputName(name)))
+			return false
+		}
+	}
+
+	cfg := &Config{
+		Action:                    Action(getenv("INPUT_ACTION")),
+		Mode:                      Mode(getenv("INPUT_MODE")),
+		Force:                     getBool("INPUT_FORCE"),
+		DryRun:                    getBool("INPUT_DRY_RUN"),
+		Debug:                     getBool("INPUT_DEBUG") || getenv("RUNNER_DEBUG") == "1",
+		AccessToken:               getenv("INPUT_GITHUB_ACCESS_TOKEN"),
+		AppPrivateKey:             getenv("INPUT_GITHUB_APP_PRIVATE_KEY"),
+		Provider:                  Provider(getenv("INPUT_PROVIDER")),
+		ProviderAPIURL:            getenv("INPUT_PROVIDER_API_URL"),
+		APIURL:                    getenv("INPUT_GITHUB_API_URL"),
+		CABundle:                  getenv("INPUT_CA_BUNDLE"),
+		SpeakeasyVersion:          getenv("INPUT_SPEAKEASY_VERSION"),
+		SpeakeasyCacheDir:         getenv("INPUT_SPEAKEASY_CACHE_DIR"),
+		SpeakeasyCLIAsset:         getenv("INPUT_SPEAKEASY_CLI_ASSET"),
+		SpeakeasyPath:             getenv("INPUT_SPEAKEASY_PATH"),
+		SpeakeasyAllowPrereleases: getBool("INPUT_SPEAKEASY_ALLOW_PRERELEASES"),
+		OpenAPIDocLocation:        getenv("INPUT_OPENAPI_DOC_LOCATION"),
+		OpenAPIDocAuthHeader:      getenv("INPUT_OPENAPI_DOC_AUTH_HEADER"),
+		OpenAPIDocAuthToken:       getenv("INPUT_OPENAPI_DOC_AUTH_TOKEN"),
+		Languages:                 getenv("INPUT_LANGUAGES"),
+		CreateRelease:             getBool("INPUT_CREATE_RELEASE"),
+		ExistingReleasePolicy:     ExistingReleasePolicy(getenv("INPUT_EXISTING_RELEASE_POLICY")),
+		PublishedLanguages:        map[string]bool{},
+		BranchName:                getenv("INPUT_BRANCH_NAME"),
+		PreviousGenVersion:        getenv("INPUT_PREVIOUS_GEN_VERSION"),
+		OpenAPIChangeReport:       getenv("INPUT_OPENAPI_CHANGE_REPORT"),
+		VersionBump:               getenv("INPUT_VERSION_BUMP"),
+		PRLabels:                  getList(getenv("INPUT_PR_LABELS")),
+		PRReviewers:               getList(getenv("INPUT_PR_REVIEWERS")),
+		PRTeamReviewers:           getList(getenv("INPUT_PR_TEAM_REVIEWERS")),
+		PRAssignees:               getList(getenv("INPUT_PR_ASSIGNEES")),
+		PRMilestone:               getenv("INPUT_PR_MILESTONE"),
+		PRDraft:                   getBool("INPUT_PR_DRAFT"),
+		TemplatesDir:              getenv("INPUT_TEMPLATES_DIR"),
+		ConventionalCommits:       getBool("INPUT_CONVENTIONAL_COMMITS"),
+		PerLanguageCommits:        getBool("INPUT_PER_LANGUAGE_COMMITS"),
+		CommitAuthorName:          getenv("INPUT_COMMIT_AUTHOR_NAME"),
+		CommitAuthorEmail:         getenv("INPUT_COMMIT_AUTHOR_EMAIL"),
+		CommitterName:             getenv("INPUT_COMMITTER_NAME"),
+		CommitterEmail:            getenv("INPUT_COMMITTER_EMAIL"),
+		SigningKey:                getenv("INPUT_SIGNING_KEY"),
+		SigningKeyPassphrase:      getenv("INPUT_SIGNING_KEY_PASSPHRASE"),
+		AutoMerge:                 getBool("INPUT_AUTO_MERGE"),
+		AutoMergeMethod:           MergeMethod(getenv("INPUT_AUTO_MERGE_METHOD")),
+		AutoMergeSkipMajor:        getBool("INPUT_AUTO_MERGE_SKIP_MAJOR"),
+		MergeStrategy:             MergeStrategy(getenv("INPUT_MERGE_STRATEGY")),
+		MergeConflictFallback:     MergeConflictFallback(getenv("INPUT_MERGE_CONFLICT_FALLBACK")),
+		ConcurrencyLock:           getBool("INPUT_CONCURRENCY_LOCK"),
+		LockTimeout:               10 * time.Minute,
+		LockExpiry:                time.Hour,
+		CleanupMaxAge:             24 * time.Hour,
+		ServerURL:                 getenv("GITHUB_SERVER_URL"),
+		Repository:                getenv("GITHUB_REPOSITORY"),
+		RepositoryOwner:           getenv("GITHUB_REPOSITORY_OWNER"),
+		Ref:                       getenv("GITHUB_REF"),
+		WorkflowName:              getenv("GITHUB_WORKFLOW"),
+		RunID:                     getenv("GITHUB_RUN_ID"),
+		EventPath:                 getenv("GITHUB_EVENT_PATH"),
+		OutputPath:                getenv("GITHUB_OUTPUT"),
+	}
+
+	if cfg.Action == "" {
+		cfg.Action = ActionGenerate
+	}
+	if cfg.Mode == "" {
+		cfg.Mode = ModeDirect
+	}
+	if cfg.Provider == "" {
+		cfg.Provider = ProviderGitHub
+	}
+	if cfg.Provider == ProviderGitHub {
+		if cfg.APIURL == "" {
+			cfg.APIURL = getenv("GITHUB_API_URL")
+		}
+		// GitHub Enterprise Server serves the REST API under /api/v3 of the server
+		if cfg.APIURL == "" && cfg.ServerURL != "" && strings.TrimSuffix(cfg.ServerURL, "/") != githubServerURL {
+			cfg.APIURL = strings.TrimSuffix(cfg.ServerURL, "/") + "/api/v3"
+		}
+	}
+	if cfg.TemplatesDir == "" {
+		cfg.TemplatesDir = ".speakeasy/templates"
+	}
+	if cfg.CommitAuthorName == "" {
+		cfg.CommitAuthorName = "speakeasybot"
+	}
+	if cfg.CommitAuthorEmail == "" {
+		cfg.CommitAuthorEmail = "bot@speakeasyapi.dev"
+	}
+	if cfg.CommitterName == "" {
+		cfg.CommitterName = cfg.CommitAuthorName
+	}
+	if cfg.CommitterEmail == "" {
+		cfg.CommitterEmail = cfg.CommitAuthorEmail
+	}
+	if cfg.AutoMergeMethod == "" {
+		cfg.AutoMergeMethod = MergeMethodMerge
+	}
+	if cfg.MergeStrategy == "" {
+		cfg.MergeStrategy = MergeStrategyMerge
+	}
+	if cfg.MergeConflictFallback == "" {
+		cfg.MergeConflictFallback = MergeConflictFallbackFail
+	}
+	if cfg.ExistingReleasePolicy == "" {
+		cfg.ExistingReleasePolicy = ExistingReleaseSkip
+	}
+
+	for _, lang := range publishableLanguages {
+		cfg.PublishedLanguages[lang] = getBool(fmt.Sprintf("INPUT_PUBLISH_%s", strings.ToUpper(lang)))
+	}
+
+	if maxParallel := getenv("INPUT_MAX_PARALLEL_GENERATIONS"); maxParallel != "" {
+		parsed, err := strconv.Atoi(maxParallel)
+		if err != nil || parsed < 0 {
+			errs = append(errs, fmt.Sprintf("invalid value %q for max_parallel_generations, expected a non-negative integer, or 0 to generate all languages in parallel", maxParallel))
+		}
+		cfg.MaxParallelGenerations = parsed
+	}
+
+	if retries := getenv("INPUT_MERGE_RETRIES"); retries != "" {
+		parsed, err := strconv.Atoi(retries)
+		if err != nil || parsed < 0 {
+			errs = append(errs, fmt.Sprintf("invalid value %q for merge_retries, expected a non-negative integer, or 0 to not retry", retries))
+		}
+		cfg.MergeRetries = parsed
+	}
+
+	getID := func(name string, value *int64) {
+		if raw := getenv(name); raw != "" {
+			parsed, err := strconv.ParseInt(raw, 10, 64)
+			if err != nil || parsed <= 0 {
+				errs = append(errs, fmt.Sprintf("invalid value %q for %s, expected a numeric ID", raw, inputName(name)))
+				return
+			}
+			*value = parsed
+		}
+	}
+
+	getID("INPUT_GITHUB_APP_ID", &cfg.AppID)
+	getID("INPUT_GITHUB_APP_INSTALLATION_ID", &cfg.AppInstallationID)
+
+	getDuration := func(name string, value *time.Duration) {
+		if raw := getenv(name); raw != "" {
+			parsed, err := time.ParseDuration(raw)
+			if err != nil || parsed <= 0 {
+				errs = append(errs, fmt.Sprintf("invalid value %q for %s, expected a duration such as 10m", raw, inputName(name)))
+				return
+			}
+			*value = parsed
+		}
+	}
+
+	getDuration("INPUT_LOCK_TIMEOUT", &cfg.LockTimeout)
+	getDuration("INPUT_LOCK_EXPIRY", &cfg.LockExpiry)
+	getDuration("INPUT_CLEANUP_MAX_AGE", &cfg.CleanupMaxAge)
+
+	errs = append(errs, cfg.validate()...)
+
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("invalid configuration:\n  %s", strings.Join(errs, "\n  "))
+	}
+
+	return cfg, nil
+}
+
+func (c *Config) validate() []string {
+	var errs []string
+
+	if !contains(validActions, c.Action) {
+		errs = append(errs, fmt.Sprintf("invalid action %q, valid options are %s", c.Action, join(validActions)))
+	}
+	if !contains(validModes, c.Mode) {
+		errs = append(errs, fmt.Sprintf("invalid mode %q, valid options are %s", c.Mode, join(validModes)))
+	}
+	if !contains(validProviders, c.Provider) {
+		errs = append(errs, fmt.Sprintf("invalid provider %q, valid options are %s", c.Provider, join(validProviders)))
+	}
+	if !contains(validMergeMethods, c.AutoMergeMethod) {
+		errs = append(errs, fmt.Sprintf("invalid auto_merge_method %q, valid options are %s", c.AutoMergeMethod, join(validMergeMethods)))
+	}
+	if !contains(validMergeStrategies, c.MergeStrategy) {
+		errs = append(errs, fmt.Sprintf("invalid merge_strategy %q, valid options are %s", c.MergeStrategy, join(validMergeStrategies)))
+	}
+	if !contains(validMergeConflictFallbacks, c.MergeConflictFallback) {
+		errs = append(errs, fmt.Sprintf("invalid merge_conflict_fallback %q, valid options are %s", c.MergeConflictFallback, join(validMergeConflictFallbacks)))
+	}
+	if !contains(validExistingReleasePolicies, c.ExistingReleasePolicy) {
+		errs = append(errs, fmt.Sprintf("invalid existing_release_policy %q, valid options are %s", c.ExistingReleasePolicy, join(validExistingReleasePolicies)))
+	}
+	if c.VersionBump != "" && !contains(validVersionBumps, c.VersionBump) {
+		errs = append(errs, fmt.Sprintf("invalid version_bump %q, valid options are %s", c.VersionBump, join(validVersionBumps)))
+	}
+
+	if c.UseGithubApp() {
+		if c.AccessToken != "" {
+			errs = append(errs, "github_access_token and github_app_id can't be used together")
+		}
+		if c.AppInstallationID == 0 {
+			errs = append(errs, "github_app_installation_id is required when github_app_id is set")
+		}
+		if c.AppPrivateKey == "" {
+			errs = append(errs, "github_app_private_key is required when github_app_id is set")
+		}
+		if c.Provider != ProviderGitHub {
+			errs = append(errs, "github_app_id is only supported by the github provider")
+		}
+	} else if c.AccessToken == "" {
+		errs = append(errs, "github_access_token is required, or github_app_id to authenticate as a GitHub App")
+	}
+	if c.Repository == "" {
+		errs = append(errs, "GITHUB_REPOSITORY is required")
+	}
+
+	if c.APIURL != "" && c.Provider != ProviderGitHub {
+		errs = append(errs, "github_api_url is only supported by the github provider")
+	}
+	if c.ProviderAPIURL != "" && c.Provider != ProviderGitea {
+		errs = append(errs, "provider_api_url is only supported by the gitea provider")
+	}
+	if c.SpeakeasyPath != "" && c.SpeakeasyCLIAsset != "" {
+		errs = append(errs, "speakeasy_path and speakeasy_cli_asset can't be used together")
+	}
+	if c.SigningKeyPassphrase != "" && c.SigningKey == "" {
+		errs = append(errs, "signing_key is required when signing_key_passphrase is set")
+	}
+	// The lock is owned by the workflow run so the finalize job can release the lock acquired by the generate job
+	if c.ConcurrencyLock && c.RunID == "" {
+		errs = append(errs, "GITHUB_RUN_ID is required when concurrency_lock is set")
+	}
+	if c.OpenAPIDocAuthHeader != "" && c.OpenAPIDocAuthToken == "" {
+		errs = append(errs, "openapi_doc_auth_token is required when openapi_doc_auth_header is set")
+	}
+
+	switch c.Action {
+	case ActionGenerate:
+		if c.OpenAPIDocLocation == "" {
+			errs = append(errs, "openapi_doc_location is required for the generate action")
+		}
+		if c.Languages == "" {
+			errs = append(errs, "languages is required for the generate action")
+		}
+	case ActionFinalize:
+		if c.BranchName == "" {
+			errs = append(errs, "branch_name is required for the finalize action")
+		}
+		if c.Languages == "" {
+			errs = append(errs, "languages is required for the finalize action")
+		}
+		// Retrying a merge regenerates the SDKs on top of the updated base branch
+		if c.Mode == ModeDirect && c.MergeRetries > 0 && c.OpenAPIDocLocation == "" {
+			errs = append(errs, "openapi_doc_location is required for the finalize action when merge_retries is set")
+		}
+	}
+
+	return errs
+}
+
+func (c *Config) IsLanguagePublished(lang string) bool {
+	if lang == "go" {
+		return c.CreateRelease
+	}
+
+	return c.PublishedLanguages[lang]
+}
+
+func (c *Config) CreateGitRelease() bool {
+	return c.CreateRelease || c.IsLanguagePublished("php")
+}
+
+// UseGithubApp reports whether to authenticate as a GitHub App installation rather than with an access token
+func (c *Config) UseGithubApp() bool {
+	return c.AppID != 0
+}
+
+// GetRepoName returns the name of the repo without the owner
+func (c *Config) GetRepoName() string {
+	parts := strings.Split(c.Repository, "/")
+	return parts[len(parts)-1]
+}
+
+// getList splits a comma or newline separated input into its trimmed non-empty values
+func getList(value string) []string {
+	values := []string{}
+
+	for _, v := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == '\n' }) {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+
+	return values
+}
+
+func inputName(envVar string) string {
+	return strings.ToLower(strings.TrimPrefix(envVar, "INPUT_"))
+}
+
+func contains[T comparable](values []T, value T) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+func join[T ~string](values []T) string {
+	strs := make([]string, 0, len(values))
+	for _, v := range values {
+		strs = append(strs, fmt.Sprintf("'%s'", v))
+	}
+
+	return strings.Join(strs, ", ")
+}
@@ -1,8 +1,8 @@
 package environment
 
 import (
-	"fmt"
 	"os"
+	"regexp"
 	"strings"
 	"time"
 )
@@ -17,121 +17,91 @@ const (
 type Action string
 
 const (
-	ActionGenerate Action = "generate"
-	ActionFinalize Action = "finalize"
-	ActionRelease  Action = "release"
+	ActionGenerate    Action = "generate"
+	ActionFinalize    Action = "finalize"
+	ActionRelease     Action = "release"
+	ActionCleanup     Action = "cleanup"
+	ActionReleaseLock Action = "release-lock"
 )
 
-var (
-	baseDir    = "/"
-	invokeTime = time.Now()
-)
+type Provider string
 
-func init() {
-	// Allows us to run this locally
-	if os.Getenv("SPEAKEASY_ENVIRONMENT") == "local" {
-		baseDir = "./"
-	}
-}
+const (
+	ProviderGitHub Provider = "github"
+	ProviderGitea  Provider = "gitea"
+)
 
-func GetBaseDir() string {
-	return baseDir
-}
+type MergeMethod string
 
-func IsDebugMode() bool {
-	return os.Getenv("INPUT_DEBUG") == "true" || os.Getenv("RUNNER_DEBUG") == "1"
-}
+const (
+	MergeMethodMerge  MergeMethod = "merge"
+	MergeMethodSquash MergeMethod = "squash"
+	MergeMethodRebase MergeMethod = "rebase"
+)
 
-func ForceGeneration() bool {
-	return os.Getenv("INPUT_FORCE") == "true"
-}
+// MergeStrategy is how the regeneration branch is merged into the base branch in direct mode
+type MergeStrategy string
 
-func GetMode() Mode {
-	mode := os.Getenv("INPUT_MODE")
-	if mode == "" {
-		return ModeDirect
-	}
+const (
+	MergeStrategyFastForward MergeStrategy = "ff-only"
+	MergeStrategyMerge       MergeStrategy = "merge"
+	MergeStrategySquash      MergeStrategy = "squash"
+	MergeStrategyRebase      MergeStrategy = "rebase"
+)
 
-	return Mode(mode)
-}
+// MergeConflictFallback is what happens when the regeneration branch can't be merged in direct mode
+type MergeConflictFallback string
 
-func GetAction() Action {
-	action := os.Getenv("INPUT_ACTION")
-	if action == "" {
-		return ActionGenerate
-	}
+const (
+	MergeConflictFallbackFail MergeConflictFallback = "fail"
+	MergeConflictFallbackPR   MergeConflictFallback = "pr"
+)
 
-	return Action(action)
-}
+// ExistingReleasePolicy is what happens when the release for a language already exists, such as when the release step is re-run
+type ExistingReleasePolicy string
 
-func GetPinnedSpeakeasyVersion() string {
-	return os.Getenv("INPUT_SPEAKEASY_VERSION")
-}
+const (
+	ExistingReleaseSkip   ExistingReleasePolicy = "skip"
+	ExistingReleaseUpdate ExistingReleasePolicy = "update"
+	ExistingReleaseFail   ExistingReleasePolicy = "fail"
+)
 
-func GetOpenAPIDocLocation() string {
-	return os.Getenv("INPUT_OPENAPI_DOC_LOCATION")
-}
+var (
+	baseDir    = "/"
+	invokeTime = time.Now()
 
-func GetLanguages() string {
-	return os.Getenv("INPUT_LANGUAGES")
-}
+	// secretEnvKey matches the environment variables holding credentials, such as INPUT_GITHUB_ACCESS_TOKEN or INPUT_SIGNING_KEY
+	secretEnvKey = regexp.MustCompile(`(?i)KEY|TOKEN|PASSPHRASE|SECRET`)
+)
 
-func CreateGitRelease() bool {
-	return os.Getenv("INPUT_CREATE_RELEASE") == "true" || IsLanguagePublished("php")
+func init() {
+	// Allows us to run this locally
+	if os.Getenv("SPEAKEASY_ENVIRONMENT") == "local" {
+		baseDir = "./"
+	}
 }
 
-func GetAccessToken() string {
-	return os.Getenv("INPUT_GITHUB_ACCESS_TOKEN")
+func GetBaseDir() string {
+	return baseDir
 }
 
 func GetInvokeTime() time.Time {
 	return invokeTime
 }
 
-func IsLanguagePublished(lang string) bool {
-	if lang == "go" {
-		return os.Getenv("INPUT_CREATE_RELEASE") == "true"
-	}
-
-	return os.Getenv(fmt.Sprintf("INPUT_PUBLISH_%s", strings.ToUpper(lang))) == "true"
-}
+// Redact replaces the values of the environment variables holding credentials, given as KEY=value pairs, so they can be
+// printed in debug mode
+func Redact(envs []string) []string {
+	redacted := make([]string, 0, len(envs))
 
-func IsJavaPublished() bool {
-	return os.Getenv("INPUT_PUBLISH_JAVA") == "true"
-}
+	for _, env := range envs {
+		key, value, _ := strings.Cut(env, "=")
+		if value != "" && secretEnvKey.MatchString(key) {
+			env = key + "=[REDACTED]"
+		}
 
-func GetOpenAPIDocAuthHeader() string {
-	return os.Getenv("INPUT_OPENAPI_DOC_AUTH_HEADER")
-}
-
-func GetOpenAPIDocAuthToken() string {
-	return os.Getenv("INPUT_OPENAPI_DOC_AUTH_TOKEN")
-}
-
-func GetWorkflowName() string {
-	return os.Getenv("GITHUB_WORKFLOW")
-}
-
-func GetWorkflowEventPayloadPath() string {
-	return os.Getenv("GITHUB_EVENT_PATH")
-}
-
-func GetBranchName() string {
-	return os.Getenv("INPUT_BRANCH_NAME")
-}
-
-func GetRef() string {
-	return os.Getenv("GITHUB_REF")
-}
-
-func GetPreviousGenVersion() string {
-	return os.Getenv("INPUT_PREVIOUS_GEN_VERSION")
-}
-
-func GetRepo() string {
-	return os.Getenv("GITHUB_REPOSITORY")
-}
+		redacted = append(redacted, env)
+	}
 
-func GetGithubServerURL() string {
-	return os.Getenv("GITHUB_SERVER_URL")
+	return redacted
 }
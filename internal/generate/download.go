@@ -13,7 +13,7 @@ import (
 	"github.com/speakeasy-api/sdk-generation-action/internal/environment"
 )
 
-func getOpenAPIFileInfo(openAPIPath string) (string, string, string, error) {
+func getOpenAPIFileInfo(actionCfg *environment.Config, openAPIPath string) (string, string, string, error) {
 	var filePath string
 
 	baseDir := environment.GetBaseDir()
@@ -32,7 +32,7 @@ func getOpenAPIFileInfo(openAPIPath string) (string, string, string, error) {
 
 		fmt.Println("Downloading openapi file from: ", u.String())
 
-		filePath, err = download.DownloadFile(u.String(), "openapi", environment.GetOpenAPIDocAuthHeader(), environment.GetOpenAPIDocAuthToken())
+		filePath, err = download.DownloadFile(u.String(), "openapi", actionCfg.OpenAPIDocAuthHeader, actionCfg.OpenAPIDocAuthToken)
 		if err != nil {
 			return "", "", "", fmt.Errorf("failed to download openapi file: %w", err)
 		}
@@ -4,17 +4,23 @@ import (
 	"fmt"
 	"path"
 	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
 
 	"github.com/hashicorp/go-version"
 	config "github.com/speakeasy-api/sdk-gen-config"
 	"github.com/speakeasy-api/sdk-generation-action/internal/cli"
 	"github.com/speakeasy-api/sdk-generation-action/internal/configuration"
 	"github.com/speakeasy-api/sdk-generation-action/internal/environment"
+	"github.com/speakeasy-api/sdk-generation-action/internal/openapi"
 )
 
 type LanguageGenInfo struct {
-	PackageName string
-	Version     string
+	PackageName     string
+	Version         string
+	PreviousVersion string
+	BumpReasons     []string
 }
 
 type GenerationInfo struct {
@@ -22,19 +28,20 @@ type GenerationInfo struct {
 	GenerationVersion string
 	OpenAPIDocVersion string
 	Languages         map[string]LanguageGenInfo
+	ChangeReport      *openapi.ChangeReport
 }
 
 type Git interface {
 	CheckDirDirty(dir string) (bool, error)
 }
 
-func Generate(g Git) (*GenerationInfo, map[string]string, error) {
-	langs, err := configuration.GetAndValidateLanguages(true)
+func Generate(actionCfg *environment.Config, g Git) (*GenerationInfo, map[string]string, error) {
+	langs, err := configuration.GetAndValidateLanguages(actionCfg.Languages, true)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	docPath, docChecksum, docVersion, err := getOpenAPIFileInfo(environment.GetOpenAPIDocLocation())
+	docPath, docChecksum, docVersion, err := getOpenAPIFileInfo(actionCfg, actionCfg.OpenAPIDocLocation)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -55,14 +62,12 @@ func Generate(g Git) (*GenerationInfo, map[string]string, error) {
 		return nil, nil, fmt.Errorf("failed to get generation version: %w", err)
 	}
 
-	langGenerated := map[string]bool{}
 	outputs := map[string]string{}
 
 	globalPreviousGenVersion := ""
+	previousGenVersions := map[string]string{}
 
 	for lang, cfg := range genConfigs {
-		dir := langs[lang]
-
 		langCfg, ok := cfg.Config.Languages[lang]
 		if !ok {
 			langCfg = config.LanguageConfig{
@@ -76,13 +81,12 @@ func Generate(g Git) (*GenerationInfo, map[string]string, error) {
 			cfg.Config.Management = &config.Management{}
 		}
 
-		sdkVersion := langCfg.Version
-
 		// Older versions of the gen.yaml won't have a generation version
 		previousGenVersion := cfg.Config.Management.GenerationVersion
 		if previousGenVersion == "" {
 			previousGenVersion = cfg.Config.Management.SpeakeasyVersion
 		}
+		previousGenVersions[lang] = previousGenVersion
 
 		if globalPreviousGenVersion == "" {
 			globalPreviousGenVersion = previousGenVersion
@@ -101,71 +105,70 @@ func Generate(g Git) (*GenerationInfo, map[string]string, error) {
 				globalPreviousGenVersion = previousGenVersion
 			}
 		}
+	}
 
-		newVersion, err := checkForChanges(generationVersion, previousGenVersion, docVersion, docChecksum, sdkVersion, cfg.Config.Management)
-		if err != nil {
-			return nil, nil, err
+	// Languages sharing a config dir are generated into the same directory so they need to be generated one after another
+	langsByConfigDir := map[string][]string{}
+	configDirs := []string{}
+	for _, lang := range sortedLanguages(genConfigs) {
+		configDir := genConfigs[lang].ConfigDir
+		if _, ok := langsByConfigDir[configDir]; !ok {
+			configDirs = append(configDirs, configDir)
 		}
+		langsByConfigDir[configDir] = append(langsByConfigDir[configDir], lang)
+	}
 
-		if newVersion != "" {
-			fmt.Println("New version detected: ", newVersion)
-			outputDir := path.Join(baseDir, "repo", dir)
-
-			langCfg.Version = newVersion
-			cfg.Config.Languages[lang] = langCfg
-
-			if err := config.Save(cfg.ConfigDir, cfg.Config); err != nil {
-				return nil, nil, err
-			}
-
-			fmt.Printf("Generating %s SDK in %s\n", lang, outputDir)
-
-			published := environment.IsLanguagePublished(lang)
-			installationURL := getInstallationURL(lang, dir)
-			if installationURL == "" {
-				published = true // Treat as published if we don't have an installation URL
-			}
-
-			if err := cli.Generate(docPath, lang, outputDir, installationURL, published); err != nil {
-				return nil, nil, err
-			}
-
-			// Load the config again as it could have been modified by the generator
-			loadedCfg, err := config.Load(outputDir)
-			if err != nil {
-				return nil, nil, err
-			}
-
-			cfg.Config = loadedCfg
-			genConfigs[lang] = cfg
-
-			dirForOutput := dir
-			if dirForOutput == "" {
-				dirForOutput = "."
-			}
-
-			outputs[fmt.Sprintf("%s_directory", lang)] = dirForOutput
+	workers := actionCfg.MaxParallelGenerations
+	if workers <= 0 || workers > len(configDirs) {
+		workers = len(configDirs)
+	}
 
-			dirty, err := g.CheckDirDirty(dir)
-			if err != nil {
-				return nil, nil, err
+	var (
+		mu                   sync.Mutex
+		wg                   sync.WaitGroup
+		langGenerated        = map[string]bool{}
+		langBumpReasons      = map[string][]string{}
+		langPreviousVersions = map[string]string{}
+		langReports          = map[string]*openapi.ChangeReport{}
+		langErrs             = map[string]error{}
+		jobs                 = make(chan string)
+	)
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for configDir := range jobs {
+				for _, lang := range langsByConfigDir[configDir] {
+					res, err := generateLanguage(actionCfg, g, &mu, lang, langs[lang], genConfigs[lang], docPath, docVersion, docChecksum, generationVersion, previousGenVersions[lang])
+
+					mu.Lock()
+					if err != nil {
+						langErrs[lang] = err
+					} else {
+						langGenerated[lang] = res.generated
+						langReports[lang] = res.changeReport
+						langBumpReasons[lang] = res.bumpReasons
+						langPreviousVersions[lang] = res.previousVersion
+						if res.dir != "" {
+							outputs[fmt.Sprintf("%s_directory", lang)] = res.dir
+						}
+					}
+					mu.Unlock()
+				}
 			}
+		}()
+	}
 
-			if dirty {
-				langGenerated[lang] = true
-			} else {
-				langCfg.Version = sdkVersion
-				cfg.Config.Languages[lang] = langCfg
-
-				if err := config.Save(cfg.ConfigDir, cfg.Config); err != nil {
-					return nil, nil, err
-				}
+	for _, configDir := range configDirs {
+		jobs <- configDir
+	}
+	close(jobs)
+	wg.Wait()
 
-				fmt.Printf("Regenerating %s SDK did not result in any changes\n", lang)
-			}
-		} else {
-			fmt.Println("No changes detected")
-		}
+	if len(langErrs) > 0 {
+		return nil, nil, newGenerationError(langErrs)
 	}
 
 	outputs["previous_gen_version"] = globalPreviousGenVersion
@@ -173,8 +176,10 @@ func Generate(g Git) (*GenerationInfo, map[string]string, error) {
 	regenerated := false
 
 	langGenInfo := map[string]LanguageGenInfo{}
+	changeReports := []*openapi.ChangeReport{}
 
-	for lang, cfg := range genConfigs {
+	for _, lang := range sortedLanguages(genConfigs) {
+		cfg := genConfigs[lang]
 		if langGenerated[lang] {
 			outputs[lang+"_regenerated"] = "true"
 
@@ -190,18 +195,28 @@ func Generate(g Git) (*GenerationInfo, map[string]string, error) {
 				return nil, nil, err
 			}
 
+			if err := saveSnapshot(cfg.ConfigDir, docPath); err != nil {
+				return nil, nil, err
+			}
+
+			changeReports = append(changeReports, langReports[lang])
+
 			langCfg := cfg.Config.Languages[lang]
 
 			switch lang {
 			case "java":
 				langGenInfo[lang] = LanguageGenInfo{
-					PackageName: fmt.Sprintf("%s.%s", langCfg.Cfg["groupID"], langCfg.Cfg["artifactID"]),
-					Version:     langCfg.Version,
+					PackageName:     fmt.Sprintf("%s.%s", langCfg.Cfg["groupID"], langCfg.Cfg["artifactID"]),
+					Version:         langCfg.Version,
+					PreviousVersion: langPreviousVersions[lang],
+					BumpReasons:     langBumpReasons[lang],
 				}
 			default:
 				langGenInfo[lang] = LanguageGenInfo{
-					PackageName: fmt.Sprintf("%s", langCfg.Cfg["packageName"]),
-					Version:     langCfg.Version,
+					PackageName:     fmt.Sprintf("%s", langCfg.Cfg["packageName"]),
+					Version:         langCfg.Version,
+					PreviousVersion: langPreviousVersions[lang],
+					BumpReasons:     langBumpReasons[lang],
 				}
 			}
 
@@ -217,12 +232,139 @@ func Generate(g Git) (*GenerationInfo, map[string]string, error) {
 			GenerationVersion: generationVersion.String(),
 			OpenAPIDocVersion: docVersion,
 			Languages:         langGenInfo,
+			ChangeReport:      openapi.Merge(changeReports...),
+		}
+
+		if genInfo.ChangeReport != nil {
+			report, err := genInfo.ChangeReport.JSON()
+			if err != nil {
+				return nil, nil, err
+			}
+
+			outputs["openapi_change_report"] = report
 		}
 	}
 
 	return genInfo, outputs, nil
 }
 
+type langGenResult struct {
+	generated    bool
+	dir          string
+	changeReport *openapi.ChangeReport
+	bumpReasons  []string
+	// previousVersion is the SDK version before it was bumped
+	previousVersion string
+}
+
+// generateLanguage generates the SDK for a single language if changes are detected, mu guards access to the shared generator configs and git worktree
+func generateLanguage(actionCfg *environment.Config, g Git, mu *sync.Mutex, lang, dir string, cfg *configuration.GenConfig, docPath, docVersion, docChecksum string, generationVersion *version.Version, previousGenVersion string) (*langGenResult, error) {
+	mu.Lock()
+	langCfg := cfg.Config.Languages[lang]
+	sdkVersion := langCfg.Version
+
+	changeReport, err := getChangeReport(cfg.ConfigDir, docPath, docChecksum, cfg.Config.Management)
+	if err != nil {
+		mu.Unlock()
+		return nil, err
+	}
+
+	newVersion, bumpReasons, err := checkForChanges(actionCfg.Force, generationVersion, previousGenVersion, docVersion, docChecksum, sdkVersion, cfg.Config.Management, changeReport)
+	if err != nil {
+		mu.Unlock()
+		return nil, err
+	}
+
+	if newVersion == "" {
+		mu.Unlock()
+		fmt.Printf("No changes detected for %s SDK\n", lang)
+		return &langGenResult{}, nil
+	}
+
+	fmt.Printf("New version detected for %s SDK: %s\n", lang, newVersion)
+	outputDir := path.Join(environment.GetBaseDir(), "repo", dir)
+
+	langCfg.Version = newVersion
+	cfg.Config.Languages[lang] = langCfg
+
+	err = config.Save(cfg.ConfigDir, cfg.Config)
+	mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Printf("Generating %s SDK in %s\n", lang, outputDir)
+
+	published := actionCfg.IsLanguagePublished(lang)
+	installationURL := getInstallationURL(actionCfg, lang, dir)
+	if installationURL == "" {
+		published = true // Treat as published if we don't have an installation URL
+	}
+
+	if err := cli.Generate(docPath, lang, outputDir, installationURL, published); err != nil {
+		return nil, err
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	// Load the config again as it could have been modified by the generator
+	loadedCfg, err := config.Load(outputDir)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.Config = loadedCfg
+
+	dirForOutput := dir
+	if dirForOutput == "" {
+		dirForOutput = "."
+	}
+
+	dirty, err := g.CheckDirDirty(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	if !dirty {
+		langCfg.Version = sdkVersion
+		cfg.Config.Languages[lang] = langCfg
+
+		if err := config.Save(cfg.ConfigDir, cfg.Config); err != nil {
+			return nil, err
+		}
+
+		fmt.Printf("Regenerating %s SDK did not result in any changes\n", lang)
+	}
+
+	return &langGenResult{generated: dirty, dir: dirForOutput, changeReport: changeReport, bumpReasons: bumpReasons, previousVersion: sdkVersion}, nil
+}
+
+func sortedLanguages(genConfigs map[string]*configuration.GenConfig) []string {
+	langs := make([]string, 0, len(genConfigs))
+	for lang := range genConfigs {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+
+	return langs
+}
+
+func newGenerationError(langErrs map[string]error) error {
+	langs := make([]string, 0, len(langErrs))
+	for lang := range langErrs {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+
+	msgs := make([]string, 0, len(langs))
+	for _, lang := range langs {
+		msgs = append(msgs, fmt.Sprintf("%s: %v", lang, langErrs[lang]))
+	}
+
+	return fmt.Errorf("failed to generate %d language(s):\n%s", len(langs), strings.Join(msgs, "\n"))
+}
+
 func normalizeGenVersion(v string) (*version.Version, error) {
 	genVersion, err := version.NewVersion(v)
 	if err != nil {
@@ -245,12 +387,18 @@ func normalizeGenVersion(v string) (*version.Version, error) {
 	return genVersion, nil
 }
 
-func checkForChanges(generationVersion *version.Version, previousGenVersion, docVersion, docChecksum, sdkVersion string, mgmtConfig *config.Management) (string, error) {
-	force := environment.ForceGeneration()
+func checkForChanges(force bool, generationVersion *version.Version, previousGenVersion, docVersion, docChecksum, sdkVersion string, mgmtConfig *config.Management, changeReport *openapi.ChangeReport) (string, []string, error) {
+	// reasons records why the version is bumped so it can be included in the release notes
+	reasons := []string{}
+	addReason := func(format string, args ...interface{}) {
+		reason := fmt.Sprintf(format, args...)
+		fmt.Println(reason)
+		reasons = append(reasons, reason)
+	}
 
 	genVersion, err := normalizeGenVersion(generationVersion.String())
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
 
 	if previousGenVersion == "" {
@@ -259,7 +407,7 @@ func checkForChanges(generationVersion *version.Version, previousGenVersion, doc
 
 	previousGenerationVersion, err := normalizeGenVersion(previousGenVersion)
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
 
 	if !genVersion.Equal(previousGenerationVersion) || docVersion != mgmtConfig.DocVersion || docChecksum != mgmtConfig.DocChecksum || force {
@@ -271,57 +419,73 @@ func checkForChanges(generationVersion *version.Version, previousGenVersion, doc
 			bumpMinor = true
 		} else {
 			if genVersion.Segments()[0] > previousGenerationVersion.Segments()[0] {
-				fmt.Printf("Generation version changed detected: %s > %s\n", previousGenVersion, generationVersion)
+				addReason("Generation version changed detected: %s > %s", previousGenVersion, generationVersion)
 				bumpMajor = true
 			} else if genVersion.Segments()[1] > previousGenerationVersion.Segments()[1] {
-				fmt.Printf("Generation version changed detected: %s > %s\n", previousGenVersion, generationVersion)
+				addReason("Generation version changed detected: %s > %s", previousGenVersion, generationVersion)
 				bumpMinor = true
 			} else if genVersion.Segments()[2] > previousGenerationVersion.Segments()[2] {
-				fmt.Printf("Generation version changed detected: %s > %s\n", previousGenVersion, generationVersion)
+				addReason("Generation version changed detected: %s > %s", previousGenVersion, generationVersion)
 				bumpPatch = true
 			}
 		}
 
-		docVersionUpdated := false
+		if changeReport != nil {
+			// A structural comparison against the previously generated doc takes precedence over the doc version
+			addReason("OpenAPI doc changes classified as %s", changeReport.Level)
 
-		if mgmtConfig.DocVersion == "" {
-			bumpMinor = true
+			switch changeReport.Level {
+			case openapi.ChangeLevelBreaking:
+				bumpMajor = true
+			case openapi.ChangeLevelAdditive:
+				bumpMinor = true
+			case openapi.ChangeLevelCosmetic:
+				bumpPatch = true
+			}
 		} else {
-			currentDocV, err := version.NewVersion(docVersion)
-			// If not a semver then we just deal with the checksum
-			if err == nil {
-				previousDocV, err := version.NewVersion(mgmtConfig.DocVersion)
-				if err != nil {
-					return "", fmt.Errorf("error parsing config openapi version %s: %w", mgmtConfig.DocVersion, err)
-				}
+			docVersionUpdated := false
 
-				if currentDocV.Segments()[0] > previousDocV.Segments()[0] {
-					fmt.Printf("OpenAPI doc version changed detected: %s > %s\n", mgmtConfig.DocVersion, docVersion)
-					bumpMajor = true
-					docVersionUpdated = true
-				} else if currentDocV.Segments()[1] > previousDocV.Segments()[1] {
-					fmt.Printf("OpenAPI doc version changed detected: %s > %s\n", mgmtConfig.DocVersion, docVersion)
-					bumpMinor = true
-					docVersionUpdated = true
-				} else if currentDocV.Segments()[2] > previousDocV.Segments()[2] {
-					fmt.Printf("OpenAPI doc version changed detected: %s > %s\n", mgmtConfig.DocVersion, docVersion)
-					bumpPatch = true
-					docVersionUpdated = true
-				}
+			if mgmtConfig.DocVersion == "" {
+				addReason("No previous OpenAPI doc version recorded")
+				bumpMinor = true
 			} else {
-				fmt.Println("::warning title=invalid_version::openapi version is not a semver")
+				currentDocV, err := version.NewVersion(docVersion)
+				// If not a semver then we just deal with the checksum
+				if err == nil {
+					previousDocV, err := version.NewVersion(mgmtConfig.DocVersion)
+					if err != nil {
+						return "", nil, fmt.Errorf("error parsing config openapi version %s: %w", mgmtConfig.DocVersion, err)
+					}
+
+					if currentDocV.Segments()[0] > previousDocV.Segments()[0] {
+						addReason("OpenAPI doc version changed detected: %s > %s", mgmtConfig.DocVersion, docVersion)
+						bumpMajor = true
+						docVersionUpdated = true
+					} else if currentDocV.Segments()[1] > previousDocV.Segments()[1] {
+						addReason("OpenAPI doc version changed detected: %s > %s", mgmtConfig.DocVersion, docVersion)
+						bumpMinor = true
+						docVersionUpdated = true
+					} else if currentDocV.Segments()[2] > previousDocV.Segments()[2] {
+						addReason("OpenAPI doc version changed detected: %s > %s", mgmtConfig.DocVersion, docVersion)
+						bumpPatch = true
+						docVersionUpdated = true
+					}
+				} else {
+					fmt.Println("::warning title=invalid_version::openapi version is not a semver")
+				}
 			}
-		}
 
-		if mgmtConfig.DocChecksum == "" {
-			bumpMinor = true
-		} else if docChecksum != mgmtConfig.DocChecksum {
-			bumpPatch = true
+			if mgmtConfig.DocChecksum == "" {
+				addReason("No previous OpenAPI doc checksum recorded")
+				bumpMinor = true
+			} else if docChecksum != mgmtConfig.DocChecksum {
+				bumpPatch = true
 
-			fmt.Printf("OpenAPI doc checksum changed detected: %s > %s\n", mgmtConfig.DocChecksum, docChecksum)
+				addReason("OpenAPI doc checksum changed detected: %s > %s", mgmtConfig.DocChecksum, docChecksum)
 
-			if !docVersionUpdated {
-				fmt.Println("::warning title=checksum_changed::openapi checksum changed but version did not")
+				if !docVersionUpdated {
+					fmt.Println("::warning title=checksum_changed::openapi checksum changed but version did not")
+				}
 			}
 		}
 
@@ -330,7 +494,7 @@ func checkForChanges(generationVersion *version.Version, previousGenVersion, doc
 		if sdkVersion != "" {
 			sdkV, err := version.NewVersion(sdkVersion)
 			if err != nil {
-				return "", fmt.Errorf("error parsing sdk version %s: %w", sdkVersion, err)
+				return "", nil, fmt.Errorf("error parsing sdk version %s: %w", sdkVersion, err)
 			}
 
 			major = sdkV.Segments()[0]
@@ -347,23 +511,27 @@ func checkForChanges(generationVersion *version.Version, previousGenVersion, doc
 			fmt.Println("Bumping SDK minor version")
 			minor++
 			patch = 0
-		} else if bumpPatch || environment.ForceGeneration() {
+		} else if bumpPatch || force {
 			fmt.Println("Bumping SDK patch version")
 			patch++
 		}
 
-		return fmt.Sprintf("%d.%d.%d", major, minor, patch), nil
+		if force && len(reasons) == 0 {
+			reasons = append(reasons, "Regeneration forced")
+		}
+
+		return fmt.Sprintf("%d.%d.%d", major, minor, patch), reasons, nil
 	}
 
-	return "", nil
+	return "", nil, nil
 }
 
-func getInstallationURL(lang, subdirectory string) string {
+func getInstallationURL(actionCfg *environment.Config, lang, subdirectory string) string {
 	subdirectory = filepath.Clean(subdirectory)
 
 	switch lang {
 	case "go":
-		base := fmt.Sprintf("%s/%s", environment.GetGithubServerURL(), environment.GetRepo())
+		base := fmt.Sprintf("%s/%s", actionCfg.ServerURL, actionCfg.Repository)
 
 		if subdirectory == "." {
 			return base
@@ -372,12 +540,12 @@ func getInstallationURL(lang, subdirectory string) string {
 		return base + "/" + subdirectory
 	case "typescript":
 		if subdirectory == "." {
-			return fmt.Sprintf("%s/%s", environment.GetGithubServerURL(), environment.GetRepo())
+			return fmt.Sprintf("%s/%s", actionCfg.ServerURL, actionCfg.Repository)
 		} else {
-			return fmt.Sprintf("https://gitpkg.now.sh/%s/%s", environment.GetRepo(), subdirectory)
+			return fmt.Sprintf("https://gitpkg.now.sh/%s/%s", actionCfg.Repository, subdirectory)
 		}
 	case "python":
-		base := fmt.Sprintf("%s/%s.git", environment.GetGithubServerURL(), environment.GetRepo())
+		base := fmt.Sprintf("%s/%s.git", actionCfg.ServerURL, actionCfg.Repository)
 
 		if subdirectory == "." {
 			return base
@@ -387,7 +555,7 @@ func getInstallationURL(lang, subdirectory string) string {
 	case "php":
 		// PHP doesn't support subdirectories
 		if subdirectory == "." {
-			return fmt.Sprintf("%s/%s", environment.GetGithubServerURL(), environment.GetRepo())
+			return fmt.Sprintf("%s/%s", actionCfg.ServerURL, actionCfg.Repository)
 		}
 	}
 
@@ -0,0 +1,65 @@
+package generate
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	config "github.com/speakeasy-api/sdk-gen-config"
+	"github.com/speakeasy-api/sdk-generation-action/internal/openapi"
+)
+
+// The snapshot is a copy of the OpenAPI doc used for the last generation, used to classify changes on the next generation
+const snapshotFileName = "openapi.snapshot.yaml"
+
+func getSnapshotPath(configDir string) string {
+	return filepath.Join(configDir, ".speakeasy", snapshotFileName)
+}
+
+func getChangeReport(configDir, docPath, docChecksum string, mgmtConfig *config.Management) (*openapi.ChangeReport, error) {
+	if mgmtConfig.DocChecksum == "" || mgmtConfig.DocChecksum == docChecksum {
+		return nil, nil
+	}
+
+	previous, err := os.ReadFile(getSnapshotPath(configDir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("failed to read openapi snapshot: %w", err)
+	}
+
+	current, err := os.ReadFile(docPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read openapi file: %w", err)
+	}
+
+	report, err := openapi.Compare(previous, current)
+	if err != nil {
+		// Fall back to version and checksum based detection if the previous doc can no longer be compared
+		fmt.Printf("::warning title=openapi_compare_failed::%v\n", err)
+		return nil, nil
+	}
+
+	return report, nil
+}
+
+func saveSnapshot(configDir, docPath string) error {
+	data, err := os.ReadFile(docPath)
+	if err != nil {
+		return fmt.Errorf("failed to read openapi file: %w", err)
+	}
+
+	snapshotPath := getSnapshotPath(configDir)
+
+	if err := os.MkdirAll(filepath.Dir(snapshotPath), os.ModePerm); err != nil {
+		return fmt.Errorf("failed to create snapshot directory: %w", err)
+	}
+
+	if err := os.WriteFile(snapshotPath, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write openapi snapshot: %w", err)
+	}
+
+	return nil
+}
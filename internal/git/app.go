@@ -0,0 +1,137 @@
+package git
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/go-github/v48/github"
+	"github.com/speakeasy-api/sdk-generation-action/internal/environment"
+	"golang.org/x/oauth2"
+)
+
+const (
+	// appJWTExpiry is how long the JWTs used to mint installation tokens are valid for, GitHub allows at most 10 minutes
+	appJWTExpiry = 9 * time.Minute
+	// appTokenRefreshMargin is how long before an installation token expires it is replaced, so it can't expire during a push
+	appTokenRefreshMargin = 5 * time.Minute
+)
+
+// newTokenSource returns the source of the token used to authenticate with the provider's API and the git remote
+func newTokenSource(cfg *environment.Config, httpClient *http.Client) (oauth2.TokenSource, error) {
+	if cfg.UseGithubApp() {
+		return newAppTokenSource(httpClient, cfg.AppID, cfg.AppInstallationID, cfg.AppPrivateKey, cfg.APIURL)
+	}
+
+	return oauth2.StaticTokenSource(&oauth2.Token{AccessToken: cfg.AccessToken}), nil
+}
+
+// newAppTokenSource returns a source of installation tokens for a GitHub App, minting a new one whenever the current one is about to expire
+func newAppTokenSource(httpClient *http.Client, appID, installationID int64, privateKey, apiURL string) (oauth2.TokenSource, error) {
+	key, err := parseAppPrivateKey(privateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := newGithubClient(httpClient, oauth2.ReuseTokenSource(nil, &appJWTSource{appID: appID, key: key}), apiURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return oauth2.ReuseTokenSource(nil, &installationTokenSource{client: client, installationID: installationID}), nil
+}
+
+func parseAppPrivateKey(privateKey string) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(privateKey))
+	if block == nil {
+		return nil, fmt.Errorf("failed to parse github app private key: no PEM block found")
+	}
+
+	// GitHub generates PKCS #1 keys but they may have been converted to PKCS #8
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse github app private key: %w", err)
+	}
+
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("failed to parse github app private key: expected an RSA key")
+	}
+
+	return key, nil
+}
+
+// appJWTSource signs the JWTs a GitHub App authenticates with to mint installation tokens
+type appJWTSource struct {
+	appID int64
+	key   *rsa.PrivateKey
+}
+
+func (s *appJWTSource) Token() (*oauth2.Token, error) {
+	now := time.Now()
+	expiresAt := now.Add(appJWTExpiry)
+
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal jwt header: %w", err)
+	}
+
+	claims, err := json.Marshal(map[string]int64{
+		// Backdated to allow for clock drift between the runner and GitHub
+		"iat": now.Add(-time.Minute).Unix(),
+		"exp": expiresAt.Unix(),
+		"iss": s.appID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal jwt claims: %w", err)
+	}
+
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+
+	digest := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, s.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign jwt: %w", err)
+	}
+
+	return &oauth2.Token{
+		AccessToken: unsigned + "." + base64.RawURLEncoding.EncodeToString(signature),
+		Expiry:      expiresAt,
+	}, nil
+}
+
+// installationTokenSource mints installation tokens using a client authenticated as the GitHub App
+type installationTokenSource struct {
+	client         *github.Client
+	installationID int64
+}
+
+func (s *installationTokenSource) Token() (*oauth2.Token, error) {
+	var token *github.InstallationToken
+	err := defaultRetryPolicy.do("create installation token", func(int) error {
+		var err error
+		token, _, err = s.client.Apps.CreateInstallationToken(context.Background(), s.installationID, nil)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create token for github app installation %d: %w", s.installationID, err)
+	}
+
+	return &oauth2.Token{
+		AccessToken: token.GetToken(),
+		Expiry:      token.GetExpiresAt().Add(-appTokenRefreshMargin),
+	}, nil
+}
@@ -0,0 +1,86 @@
+package git
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAppTokenSource_RefreshesExpiringTokens(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	privateKey := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	minted := 0
+	// The first token expires within the refresh margin so is replaced on its next use
+	lifetimes := []time.Duration{time.Minute, time.Hour}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/app/installations/42/access_tokens", r.URL.Path)
+
+		jwt := strings.Split(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "), ".")
+		require.Len(t, jwt, 3)
+
+		claims, err := base64.RawURLEncoding.DecodeString(jwt[1])
+		require.NoError(t, err)
+		assert.Contains(t, string(claims), `"iss":7`)
+
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"token":      fmt.Sprintf("ghs_%d", minted),
+			"expires_at": time.Now().Add(lifetimes[minted]),
+		})
+		minted++
+	}))
+	defer server.Close()
+
+	ts, err := newAppTokenSource(http.DefaultClient, 7, 42, string(privateKey), server.URL)
+	require.NoError(t, err)
+
+	token, err := ts.Token()
+	require.NoError(t, err)
+	assert.Equal(t, "ghs_0", token.AccessToken)
+
+	token, err = ts.Token()
+	require.NoError(t, err)
+	assert.Equal(t, "ghs_1", token.AccessToken)
+
+	token, err = ts.Token()
+	require.NoError(t, err)
+	assert.Equal(t, "ghs_1", token.AccessToken)
+	assert.Equal(t, 2, minted)
+}
+
+func TestParseAppPrivateKey(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+
+	for _, block := range []*pem.Block{
+		{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)},
+		{Type: "PRIVATE KEY", Bytes: pkcs8},
+	} {
+		parsed, err := parseAppPrivateKey(string(pem.EncodeToMemory(block)))
+		require.NoError(t, err, block.Type)
+		assert.True(t, key.Equal(parsed), block.Type)
+	}
+
+	_, err = parseAppPrivateKey("not a key")
+	assert.ErrorContains(t, err, "no PEM block found")
+}
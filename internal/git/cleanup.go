@@ -0,0 +1,122 @@
+package git
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/speakeasy-api/sdk-generation-action/internal/logging"
+)
+
+// CleanupResult describes the stale regeneration branches and PRs removed by Cleanup
+type CleanupResult struct {
+	DeletedBranches []string
+	ClosedPRs       []PullRequest
+}
+
+// Cleanup removes what failed or cancelled runs left behind: superseded regeneration PRs are closed, keeping the newest open,
+// and regeneration branches created more than maxAge ago without an open PR are deleted
+func (g *Git) Cleanup(maxAge time.Duration) (*CleanupResult, error) {
+	if g.repo == nil {
+		return nil, fmt.Errorf("repo not cloned")
+	}
+
+	result := &CleanupResult{
+		DeletedBranches: []string{},
+		ClosedPRs:       []PullRequest{},
+	}
+
+	regenPRs, openPRs, err := g.listRegenPRs()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(regenPRs) > 1 {
+		if err := g.closeSupersededPRs(regenPRs[0], regenPRs[1:]); err != nil {
+			return nil, err
+		}
+		result.ClosedPRs = append(result.ClosedPRs, regenPRs[1:]...)
+	}
+
+	closed := map[int]bool{}
+	for _, pr := range result.ClosedPRs {
+		closed[pr.Number] = true
+	}
+
+	// Branches are kept while any open PR, regeneration or otherwise, is based on them
+	withPR := map[string]bool{}
+	for _, pr := range openPRs {
+		if !closed[pr.Number] {
+			withPR[pr.HeadRef] = true
+		}
+	}
+
+	branches, err := g.listRegenBranches()
+	if err != nil {
+		return nil, err
+	}
+
+	cutoff := time.Now().Add(-maxAge)
+
+	for _, branchName := range branches {
+		created, ok := regenBranchCreated(branchName)
+		if !ok {
+			logging.Debug("Skipping branch %s as its creation time is unknown", branchName)
+			continue
+		}
+
+		if withPR[branchName] {
+			logging.Debug("Keeping branch %s as it has an open PR", branchName)
+			continue
+		}
+		if created.After(cutoff) {
+			logging.Debug("Keeping branch %s as it was created at %s", branchName, created.Format(time.RFC3339))
+			continue
+		}
+
+		if err := g.DeleteBranch(branchName); err != nil {
+			return nil, err
+		}
+		result.DeletedBranches = append(result.DeletedBranches, branchName)
+	}
+
+	return result, nil
+}
+
+// listRegenBranches lists the regeneration branches on the remote
+func (g *Git) listRegenBranches() ([]string, error) {
+	refs, err := g.listRemoteRefs()
+	if err != nil {
+		return nil, err
+	}
+
+	branches := []string{}
+	for _, ref := range refs {
+		if ref.Type() != plumbing.HashReference || !ref.Name().IsBranch() {
+			continue
+		}
+
+		if name := ref.Name().Short(); strings.HasPrefix(name, regenBranchPrefix) {
+			branches = append(branches, name)
+		}
+	}
+
+	sort.Strings(branches)
+
+	return branches, nil
+}
+
+// regenBranchCreated parses the creation time from the unix timestamp in the name of a regeneration branch
+func regenBranchCreated(branchName string) (time.Time, bool) {
+	timestamp, _, _ := strings.Cut(strings.TrimPrefix(branchName, regenBranchPrefix), "-")
+
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	return time.Unix(seconds, 0), true
+}
@@ -0,0 +1,28 @@
+package git
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegenBranchCreated(t *testing.T) {
+	tests := []struct {
+		branchName string
+		want       time.Time
+		wantOK     bool
+	}{
+		{branchName: "speakeasy-sdk-regen-1700000000", want: time.Unix(1700000000, 0), wantOK: true},
+		{branchName: "speakeasy-sdk-regen-1700000000-2", want: time.Unix(1700000000, 0), wantOK: true},
+		{branchName: "speakeasy-sdk-regen-manual", wantOK: false},
+		{branchName: "speakeasy-sdk-regen-", wantOK: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.branchName, func(t *testing.T) {
+			got, ok := regenBranchCreated(tt.branchName)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
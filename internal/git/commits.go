@@ -0,0 +1,119 @@
+package git
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/speakeasy-api/sdk-generation-action/internal/logging"
+	"github.com/speakeasy-api/sdk-generation-action/internal/templates"
+	"github.com/speakeasy-api/sdk-generation-action/pkg/releases"
+)
+
+// metadataFiles are committed separately from the SDKs when creating a commit per language, matched by their path relative to any directory.
+// The releases ledger is committed with RELEASES.md so the two stay in sync when a language's commit is reverted or cherry-picked.
+var metadataFiles = []string{"RELEASES.md", releases.LedgerPath, "gen.yaml"}
+
+// commitPerLanguage commits the changes to each language's directory separately followed by a commit of the remaining changes
+// such as RELEASES.md and the gen.yaml files, so a single SDK's regeneration can be reverted or cherry-picked
+func (g *Git) commitPerLanguage(w *git.Worktree, data templates.Data) ([]plumbing.Hash, error) {
+	status, err := w.Status()
+	if err != nil {
+		return nil, fmt.Errorf("error getting status: %w", err)
+	}
+
+	files := map[string][]string{}
+	for file, s := range status {
+		if s.Worktree == git.Unmodified && s.Staging == git.Unmodified {
+			continue
+		}
+
+		lang := languageForFile(file, data.Languages)
+		files[lang] = append(files[lang], file)
+	}
+
+	commitHashes := []plumbing.Hash{}
+
+	for _, lang := range data.Languages {
+		if len(files[lang.Name]) == 0 {
+			logging.Debug("No changes to commit for %s", lang.Name)
+			continue
+		}
+
+		message, err := g.render(templates.LanguageCommitMessage, data.ForLanguage(lang.Name))
+		if err != nil {
+			return nil, err
+		}
+
+		commitHash, err := g.commitFiles(w, status, files[lang.Name], message)
+		if err != nil {
+			return nil, fmt.Errorf("error committing %s: %w", lang.Name, err)
+		}
+		commitHashes = append(commitHashes, commitHash)
+	}
+
+	if len(files[""]) > 0 || len(commitHashes) == 0 {
+		message, err := g.render(templates.MetadataCommitMessage, data)
+		if err != nil {
+			return nil, err
+		}
+
+		commitHash, err := g.commitFiles(w, status, files[""], message)
+		if err != nil {
+			return nil, err
+		}
+		commitHashes = append(commitHashes, commitHash)
+	}
+
+	return commitHashes, nil
+}
+
+func (g *Git) commitFiles(w *git.Worktree, status git.Status, files []string, message string) (plumbing.Hash, error) {
+	sort.Strings(files)
+
+	for _, file := range files {
+		if status[file].Worktree == git.Deleted {
+			if _, err := w.Remove(file); err != nil {
+				return plumbing.ZeroHash, fmt.Errorf("error removing %s: %w", file, err)
+			}
+		} else if _, err := w.Add(file); err != nil {
+			return plumbing.ZeroHash, fmt.Errorf("error adding %s: %w", file, err)
+		}
+	}
+
+	return g.commit(w, message, false)
+}
+
+// languageForFile returns the name of the language whose directory most specifically contains the file,
+// or an empty string for metadata files and files outside of every language's directory
+func languageForFile(file string, languages []templates.Language) string {
+	for _, name := range metadataFiles {
+		if file == name || strings.HasSuffix(file, "/"+name) {
+			return ""
+		}
+	}
+
+	match := ""
+	matchLen := -1
+
+	for _, lang := range languages {
+		dir := path.Clean(lang.Path)
+		if dir == "." {
+			dir = ""
+		}
+
+		if dir != "" && file != dir && !strings.HasPrefix(file, dir+"/") {
+			continue
+		}
+
+		if len(dir) > matchLen {
+			match = lang.Name
+			matchLen = len(dir)
+		}
+	}
+
+	return match
+}
@@ -0,0 +1,164 @@
+package git
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-billy/v5/util"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"github.com/speakeasy-api/sdk-generation-action/internal/environment"
+	"github.com/speakeasy-api/sdk-generation-action/internal/templates"
+	"github.com/speakeasy-api/sdk-generation-action/pkg/releases"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommitPerLanguage_Success(t *testing.T) {
+	repo, err := git.Init(memory.NewStorage(), memfs.New())
+	require.NoError(t, err)
+
+	w, err := repo.Worktree()
+	require.NoError(t, err)
+
+	write := func(files map[string]string) {
+		for name, content := range files {
+			require.NoError(t, util.WriteFile(w.Filesystem, name, []byte(content), 0o644))
+		}
+	}
+
+	write(map[string]string{
+		"RELEASES.md":       "# Releases",
+		"go/gen.yaml":       "version: 1.0.0",
+		"go/sdk.go":         "package sdk",
+		"go/old.go":         "package sdk",
+		"python/gen.yaml":   "version: 1.0.0",
+		"python/sdk.py":     "",
+		"typescript/sdk.ts": "",
+	})
+	_, err = w.Add(".")
+	require.NoError(t, err)
+	_, err = w.Commit("initial", &git.CommitOptions{Author: &object.Signature{Name: "test", When: time.Now()}})
+	require.NoError(t, err)
+
+	write(map[string]string{
+		"RELEASES.md":     "# Releases\n\n## v1.1.0",
+		"go/gen.yaml":     "version: 1.1.0",
+		"go/sdk.go":       "package sdk // regenerated",
+		"go/models.go":    "package sdk",
+		"python/gen.yaml": "version: 2.0.0",
+		"python/sdk.py":   "# regenerated",
+		"gopher.txt":      "gopher",
+	})
+	require.NoError(t, w.Filesystem.Remove("go/old.go"))
+
+	tmpls, err := templates.Load(t.TempDir(), false)
+	require.NoError(t, err)
+
+	cfg := &environment.Config{CommitAuthorName: "speakeasybot", CommitAuthorEmail: "bot@speakeasyapi.dev", CommitterName: "speakeasybot", CommitterEmail: "bot@speakeasyapi.dev"}
+	g := &Git{cfg: cfg, repo: repo, templates: tmpls}
+
+	data := templates.NewData(cfg, releases.ReleasesInfo{
+		DocVersion:       "1.1.0",
+		SpeakeasyVersion: "1.20.0",
+		Languages: map[string]releases.LanguageReleaseInfo{
+			"go":     {Path: "./go", Version: "1.1.0", PreviousVersion: "1.0.0"},
+			"python": {Path: "python", Version: "2.0.0", PreviousVersion: "1.0.0"},
+		},
+	}, nil)
+
+	hashes, err := g.commitPerLanguage(w, data)
+	require.NoError(t, err)
+	require.Len(t, hashes, 3)
+
+	head, err := repo.Head()
+	require.NoError(t, err)
+	assert.Equal(t, hashes[2], head.Hash())
+
+	want := []struct {
+		message string
+		files   []string
+	}{
+		{
+			message: "ci: regenerated go SDK 1.1.0 with OpenAPI Doc 1.1.0, Speakeasy CLI 1.20.0",
+			files:   []string{"go/models.go", "go/old.go", "go/sdk.go"},
+		},
+		{
+			message: "ci: regenerated python SDK 2.0.0 with OpenAPI Doc 1.1.0, Speakeasy CLI 1.20.0",
+			files:   []string{"python/sdk.py"},
+		},
+		{
+			message: "ci: updated release metadata for OpenAPI Doc 1.1.0, Speakeasy CLI 1.20.0",
+			files:   []string{"RELEASES.md", "go/gen.yaml", "gopher.txt", "python/gen.yaml"},
+		},
+	}
+
+	for i, hash := range hashes {
+		commit, err := repo.CommitObject(hash)
+		require.NoError(t, err)
+		assert.Equal(t, want[i].message, commit.Message)
+		assert.Equal(t, "speakeasybot", commit.Author.Name)
+
+		if i > 0 {
+			assert.Equal(t, hashes[i-1], commit.ParentHashes[0])
+		}
+
+		parent, err := commit.Parent(0)
+		require.NoError(t, err)
+		parentTree, err := parent.Tree()
+		require.NoError(t, err)
+		tree, err := commit.Tree()
+		require.NoError(t, err)
+
+		changes, err := object.DiffTree(parentTree, tree)
+		require.NoError(t, err)
+
+		files := []string{}
+		for _, change := range changes {
+			name := change.To.Name
+			if name == "" {
+				name = change.From.Name
+			}
+			files = append(files, name)
+		}
+		assert.ElementsMatch(t, want[i].files, files)
+	}
+
+	status, err := w.Status()
+	require.NoError(t, err)
+	assert.True(t, status.IsClean())
+}
+
+func TestLanguageForFile(t *testing.T) {
+	languages := []templates.Language{
+		{Name: "go", Path: "."},
+		{Name: "python", Path: "./python"},
+		{Name: "typescript", Path: "sdks/typescript/"},
+	}
+
+	tests := []struct {
+		file string
+		want string
+	}{
+		{file: "sdk.go", want: "go"},
+		{file: "models/pet.go", want: "go"},
+		{file: "python/sdk.py", want: "python"},
+		{file: "pythonic/sdk.py", want: "go"},
+		{file: "sdks/typescript/src/sdk.ts", want: "typescript"},
+		{file: "gen.yaml", want: ""},
+		{file: "python/gen.yaml", want: ""},
+		{file: "RELEASES.md", want: ""},
+		{file: ".speakeasy/releases.json", want: ""},
+		{file: "python/.speakeasy/releases.json", want: ""},
+		{file: "python/releases.json", want: "python"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			assert.Equal(t, tt.want, languageForFile(tt.file, languages))
+		})
+	}
+
+	assert.Equal(t, "", languageForFile("README.md", languages[1:]))
+}
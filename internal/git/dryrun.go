@@ -0,0 +1,132 @@
+package git
+
+import (
+	"fmt"
+
+	"github.com/speakeasy-api/sdk-generation-action/internal/environment"
+	"github.com/speakeasy-api/sdk-generation-action/internal/logging"
+)
+
+// Plan records the mutating operations that would have been performed when running in dry run mode
+type Plan struct {
+	steps []string
+}
+
+func (p *Plan) record(format string, args ...interface{}) {
+	step := fmt.Sprintf(format, args...)
+	p.steps = append(p.steps, step)
+
+	logging.Info("[dry run] Would %s", step)
+}
+
+func (p *Plan) Steps() []string {
+	return p.steps
+}
+
+func (p *Plan) String() string {
+	if len(p.steps) == 0 {
+		return "Dry run plan: no changes would be made"
+	}
+
+	out := "Dry run plan:"
+	for i, step := range p.steps {
+		out += fmt.Sprintf("\n  %d. %s", i+1, step)
+	}
+
+	return out
+}
+
+// dryRunProvider wraps a provider recording any mutating operations in the plan instead of executing them
+type dryRunProvider struct {
+	Provider
+	plan *Plan
+}
+
+var _ Provider = (*dryRunProvider)(nil)
+
+func (p *dryRunProvider) CreatePullRequest(pr PullRequest) (*PullRequest, error) {
+	kind := "PR"
+	if pr.Draft {
+		kind = "draft PR"
+	}
+
+	p.plan.record("create %s %q from %s into %s with body:\n%s", kind, pr.Title, pr.HeadRef, pr.BaseRef, pr.Body)
+
+	return &pr, nil
+}
+
+func (p *dryRunProvider) UpdatePullRequest(pr PullRequest) (*PullRequest, error) {
+	kind := "PR"
+	if pr.Draft {
+		kind = "draft PR"
+	}
+
+	p.plan.record("update %s #%d %q with body:\n%s", kind, pr.Number, pr.Title, pr.Body)
+
+	return &pr, nil
+}
+
+func (p *dryRunProvider) ClosePullRequest(pr PullRequest, comment string) error {
+	p.plan.record("close PR #%d %q with comment: %s", pr.Number, pr.Title, comment)
+
+	return nil
+}
+
+func (p *dryRunProvider) SetPullRequestMetadata(pr PullRequest, metadata, stale PullRequestMetadata) error {
+	if stale.IsEmpty() {
+		p.plan.record("set metadata on PR #%d %q: %s", pr.Number, pr.Title, metadata)
+	} else {
+		p.plan.record("set metadata on PR #%d %q: %s removing %s", pr.Number, pr.Title, metadata, stale)
+	}
+
+	return nil
+}
+
+func (p *dryRunProvider) EnableAutoMerge(pr PullRequest, method environment.MergeMethod) error {
+	p.plan.record("enable auto-merge of PR #%d %q using %s", pr.Number, pr.Title, method)
+
+	return nil
+}
+
+func (p *dryRunProvider) DisableAutoMerge(pr PullRequest) error {
+	p.plan.record("disable auto-merge of PR #%d %q", pr.Number, pr.Title)
+
+	return nil
+}
+
+func (p *dryRunProvider) CreateRelease(release Release) error {
+	p.plan.record("create release %q for tag %s at %s", release.Name, release.TagName, release.TargetCommitish)
+
+	return nil
+}
+
+func (p *dryRunProvider) UpdateRelease(release Release) error {
+	p.plan.record("update release %q for tag %s", release.Name, release.TagName)
+
+	return nil
+}
+
+// PlanFinalize records the merge or PR finalizing the branch would create. The branch pushed by a dry run generate only exists in
+// its plan, so it can't be fetched to finalize it.
+func (g *Git) PlanFinalize(branchName string) error {
+	if g.plan == nil {
+		return fmt.Errorf("not running in dry run mode")
+	}
+
+	switch g.cfg.Mode {
+	case environment.ModeDirect:
+		g.plan.record("merge branch %s into %s using the %s strategy", branchName, g.cfg.Ref, g.cfg.MergeStrategy)
+
+		if g.cfg.CreateGitRelease() {
+			g.plan.record("create a release for each published language regenerated on %s", branchName)
+		}
+	default:
+		g.plan.record("create or update the PR for branch %s into %s", branchName, g.cfg.Ref)
+
+		if g.cfg.AutoMerge {
+			g.plan.record("sync auto-merge of the PR for branch %s using %s", branchName, g.cfg.AutoMergeMethod)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,102 @@
+package git
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/speakeasy-api/sdk-generation-action/internal/environment"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingProvider struct {
+	prs   []PullRequest
+	calls []string
+}
+
+func (p *recordingProvider) ListOpenPullRequests() ([]PullRequest, error) {
+	p.calls = append(p.calls, "list")
+	return p.prs, nil
+}
+
+func (p *recordingProvider) CreatePullRequest(pr PullRequest) (*PullRequest, error) {
+	p.calls = append(p.calls, "create")
+	return &pr, nil
+}
+
+func (p *recordingProvider) UpdatePullRequest(pr PullRequest) (*PullRequest, error) {
+	p.calls = append(p.calls, fmt.Sprintf("update #%d draft=%t", pr.Number, pr.Draft))
+	return &pr, nil
+}
+
+func (p *recordingProvider) ClosePullRequest(pr PullRequest, comment string) error {
+	p.calls = append(p.calls, fmt.Sprintf("close #%d: %s", pr.Number, comment))
+	return nil
+}
+
+func (p *recordingProvider) SetPullRequestMetadata(pr PullRequest, metadata, stale PullRequestMetadata) error {
+	p.calls = append(p.calls, fmt.Sprintf("metadata #%d: %s removing %s", pr.Number, metadata, stale))
+	return nil
+}
+
+func (p *recordingProvider) EnableAutoMerge(pr PullRequest, method environment.MergeMethod) error {
+	p.calls = append(p.calls, fmt.Sprintf("auto-merge #%d: %s", pr.Number, method))
+	return nil
+}
+
+func (p *recordingProvider) DisableAutoMerge(pr PullRequest) error {
+	p.calls = append(p.calls, fmt.Sprintf("disable auto-merge #%d", pr.Number))
+	return nil
+}
+
+func (p *recordingProvider) CreateRelease(release Release) error {
+	p.calls = append(p.calls, "release")
+	return nil
+}
+
+func (p *recordingProvider) UpdateRelease(release Release) error {
+	p.calls = append(p.calls, "update release")
+	return nil
+}
+
+func (p *recordingProvider) GetRelease(tagName string) (*Release, error) {
+	p.calls = append(p.calls, "get release "+tagName)
+	return nil, nil
+}
+
+func TestDryRunProvider_RecordsMutations(t *testing.T) {
+	inner := &recordingProvider{prs: []PullRequest{{Number: 1, Title: "existing"}}}
+	plan := &Plan{}
+	p := &dryRunProvider{Provider: inner, plan: plan}
+
+	prs, err := p.ListOpenPullRequests()
+	require.NoError(t, err)
+	assert.Equal(t, inner.prs, prs)
+
+	_, err = p.CreatePullRequest(PullRequest{Title: "title", Body: "body", HeadRef: "branch", BaseRef: "main"})
+	require.NoError(t, err)
+
+	_, err = p.UpdatePullRequest(PullRequest{Number: 1, Title: "existing", Body: "body"})
+	require.NoError(t, err)
+
+	require.NoError(t, p.ClosePullRequest(PullRequest{Number: 2, Title: "old"}, "superseded"))
+
+	require.NoError(t, p.SetPullRequestMetadata(PullRequest{Number: 1, Title: "existing"}, PullRequestMetadata{Labels: []string{"sdk", "automated"}, Milestone: "v2"}, PullRequestMetadata{Labels: []string{"old"}}))
+
+	require.NoError(t, p.EnableAutoMerge(PullRequest{Number: 1, Title: "existing"}, environment.MergeMethodSquash))
+
+	require.NoError(t, p.CreateRelease(Release{Name: "go - v1.0.0", TagName: "v1.0.0", TargetCommitish: "abc"}))
+
+	require.NoError(t, p.UpdateRelease(Release{Name: "go - v0.9.0", TagName: "v0.9.0"}))
+
+	assert.Equal(t, []string{"list"}, inner.calls)
+	assert.Equal(t, []string{
+		"create PR \"title\" from branch into main with body:\nbody",
+		"update PR #1 \"existing\" with body:\nbody",
+		"close PR #2 \"old\" with comment: superseded",
+		"set metadata on PR #1 \"existing\": labels=sdk,automated milestone=v2 removing labels=old",
+		"enable auto-merge of PR #1 \"existing\" using squash",
+		"create release \"go - v1.0.0\" for tag v1.0.0 at abc",
+		"update release \"go - v0.9.0\" for tag v0.9.0",
+	}, plan.Steps())
+}
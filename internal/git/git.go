@@ -4,12 +4,15 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/url"
 	"os"
 	"os/exec"
 	"path"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -22,6 +25,8 @@ import (
 	"github.com/speakeasy-api/sdk-generation-action/internal/cli"
 	"github.com/speakeasy-api/sdk-generation-action/internal/environment"
 	"github.com/speakeasy-api/sdk-generation-action/internal/logging"
+	"github.com/speakeasy-api/sdk-generation-action/internal/openapi"
+	"github.com/speakeasy-api/sdk-generation-action/internal/templates"
 	"github.com/speakeasy-api/sdk-generation-action/pkg/releases"
 
 	"github.com/google/go-github/v48/github"
@@ -29,45 +34,143 @@ import (
 )
 
 type Git struct {
-	accessToken string
+	cfg *environment.Config
+	// tokenSource provides the token for the git remote, either the access token or a GitHub App installation token
+	tokenSource oauth2.TokenSource
 	repo        *git.Repository
-	client      *github.Client
+	provider    Provider
+	// plan is only set when running in dry run mode
+	plan *Plan
+	// client is used to look up Speakeasy CLI releases which are always hosted on GitHub regardless of provider
+	client *github.Client
+	// templates are loaded from the cloned repo on first use
+	templates *templates.Templates
+	// signer is only set when commits should be signed
+	signer signer
 }
 
-func New(accessToken string) *Git {
-	ctx := context.Background()
-	ts := oauth2.StaticTokenSource(
-		&oauth2.Token{AccessToken: accessToken},
-	)
-	tc := oauth2.NewClient(ctx, ts)
+// speakeasyReleasesAPIURL is the GitHub API hosting Speakeasy CLI releases, empty for api.github.com. The end to end tests point it
+// at a fake API when building the action with -ldflags "-X github.com/speakeasy-api/sdk-generation-action/internal/git.speakeasyReleasesAPIURL=..."
+var speakeasyReleasesAPIURL string
+
+func New(cfg *environment.Config) (*Git, error) {
+	httpClient, err := newHTTPClient(cfg.CABundle)
+	if err != nil {
+		return nil, err
+	}
+
+	tokenSource, err := newTokenSource(cfg, httpClient)
+	if err != nil {
+		return nil, err
+	}
+
+	provider, err := newProvider(cfg, httpClient, tokenSource)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only tokens for the same GitHub instance are valid for the API hosting Speakeasy CLI releases, others fall back to anonymous access
+	var clientTokenSource oauth2.TokenSource
+	if cfg.Provider == environment.ProviderGitHub && sameHost(cfg.APIURL, speakeasyReleasesAPIURL) {
+		clientTokenSource = tokenSource
+	}
+
+	client, err := newGithubClient(httpClient, clientTokenSource, speakeasyReleasesAPIURL)
+	if err != nil {
+		return nil, err
+	}
+
+	provider = &retryingProvider{Provider: provider, policy: defaultRetryPolicy}
+
+	var plan *Plan
+	if cfg.DryRun {
+		plan = &Plan{}
+		provider = &dryRunProvider{Provider: provider, plan: plan}
+	}
+
+	var s signer
+	if cfg.SigningKey != "" {
+		s, err = newSigner(cfg.SigningKey, cfg.SigningKeyPassphrase)
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	return &Git{
-		accessToken: accessToken,
-		client:      github.NewClient(tc),
+		cfg:         cfg,
+		tokenSource: tokenSource,
+		provider:    provider,
+		plan:        plan,
+		client:      client,
+		signer:      s,
+	}, nil
+}
+
+// sameHost checks whether the GitHub API URLs, defaulting to api.github.com when empty, are for the same host
+func sameHost(a, b string) bool {
+	host := func(apiURL string) string {
+		if apiURL == "" {
+			return "api.github.com"
+		}
+
+		u, err := url.Parse(apiURL)
+		if err != nil {
+			return ""
+		}
+
+		return u.Host
 	}
+
+	return host(a) != "" && host(a) == host(b)
+}
+
+// PrintPlan prints the operations skipped when running in dry run mode
+func (g *Git) PrintPlan() {
+	if g.plan == nil {
+		return
+	}
+
+	fmt.Println(g.plan.String())
 }
 
 func (g *Git) CloneRepo() error {
-	githubURL := os.Getenv("GITHUB_SERVER_URL")
-	githubRepoLocation := os.Getenv("GITHUB_REPOSITORY")
+	githubURL := g.cfg.ServerURL
+	githubRepoLocation := g.cfg.Repository
 
 	repoPath, err := url.JoinPath(githubURL, githubRepoLocation)
 	if err != nil {
 		return fmt.Errorf("failed to construct repo url: %w", err)
 	}
 
-	ref := os.Getenv("GITHUB_REF")
+	ref := g.cfg.Ref
 
 	logging.Info("Cloning repo: %s from ref: %s", repoPath, ref)
 
-	baseDir := environment.GetBaseDir()
+	repoDir := path.Join(environment.GetBaseDir(), "repo")
+
+	var r *git.Repository
+	err = defaultRetryPolicy.do("clone repo", func(attempt int) error {
+		// A failed clone leaves a partial repo behind
+		if attempt > 1 {
+			if err := os.RemoveAll(repoDir); err != nil {
+				return fmt.Errorf("failed to remove partially cloned repo: %w", err)
+			}
+		}
+
+		auth, err := g.getAuth()
+		if err != nil {
+			return err
+		}
 
-	r, err := git.PlainClone(path.Join(baseDir, "repo"), false, &git.CloneOptions{
-		URL:           repoPath,
-		Progress:      os.Stdout,
-		Auth:          getGithubAuth(g.accessToken),
-		ReferenceName: plumbing.ReferenceName(ref),
-		SingleBranch:  true,
+		r, err = git.PlainClone(repoDir, false, &git.CloneOptions{
+			URL:           repoPath,
+			Progress:      os.Stdout,
+			Auth:          auth,
+			CABundle:      []byte(g.cfg.CABundle),
+			ReferenceName: plumbing.ReferenceName(ref),
+			SingleBranch:  true,
+		})
+		return err
 	})
 	if err != nil {
 		return fmt.Errorf("failed to clone repo: %w", err)
@@ -146,31 +249,99 @@ func (g *Git) CheckDirDirty(dir string) (bool, error) {
 	return IsGitDiffSignificant(diffOutput), nil
 }
 
-func (g *Git) FindExistingPR(branchName string) (string, *github.PullRequest, error) {
+// FindExistingPR finds the open regeneration PR, if multiple are found the newest is kept and the rest are closed
+func (g *Git) FindExistingPR(branchName string) (string, *PullRequest, error) {
 	if g.repo == nil {
 		return "", nil, fmt.Errorf("repo not cloned")
 	}
 
-	prs, _, err := g.client.PullRequests.List(context.Background(), os.Getenv("GITHUB_REPOSITORY_OWNER"), getRepo(), nil)
+	matches, _, err := g.listRegenPRs()
+	if err != nil {
+		return "", nil, err
+	}
+
+	if len(matches) == 0 {
+		logging.Info("Existing PR not found")
+
+		return branchName, nil, nil
+	}
+
+	pr := matches[0]
+
+	logging.Info("Found existing PR #%d %s", pr.Number, pr.Title)
+
+	if err := g.closeSupersededPRs(pr, matches[1:]); err != nil {
+		return "", nil, err
+	}
+
+	if branchName != "" && pr.HeadRef != branchName {
+		return "", nil, fmt.Errorf("existing PR has different branch name: %s than expected: %s", pr.HeadRef, branchName)
+	}
+
+	return pr.HeadRef, &pr, nil
+}
+
+// listRegenPRs returns the open regeneration PRs ordered from newest to oldest along with every open PR
+func (g *Git) listRegenPRs() ([]PullRequest, []PullRequest, error) {
+	prs, err := g.provider.ListOpenPullRequests()
 	if err != nil {
-		return "", nil, fmt.Errorf("error getting pull requests: %w", err)
+		return nil, nil, err
 	}
 
+	matches := []PullRequest{}
 	for _, p := range prs {
-		if strings.Compare(p.GetTitle(), getPRTitle()) == 0 {
-			logging.Info("Found existing PR %s", *p.Title)
+		if g.isRegenPR(p) {
+			matches = append(matches, p)
+		}
+	}
 
-			if branchName != "" && p.GetHead().GetRef() != branchName {
-				return "", nil, fmt.Errorf("existing PR has different branch name: %s than expected: %s", p.GetHead().GetRef(), branchName)
-			}
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].Number > matches[j].Number
+	})
 
-			return p.GetHead().GetRef(), p, nil
+	return matches, prs, nil
+}
+
+func (g *Git) closeSupersededPRs(latest PullRequest, superseded []PullRequest) error {
+	for _, pr := range superseded {
+		logging.Info("Closing superseded PR #%d %s", pr.Number, pr.Title)
+
+		if err := g.provider.ClosePullRequest(pr, fmt.Sprintf("Superseded by #%d, closing as only the latest regeneration PR is kept up to date.", latest.Number)); err != nil {
+			return fmt.Errorf("failed to close superseded PR #%d: %w", pr.Number, err)
 		}
 	}
 
-	logging.Info("Existing PR not found")
+	return nil
+}
+
+// isRegenPR checks whether the PR was created by this workflow of the action for the base branch, PRs created before the marker was
+// added are matched by title. PRs from forks are never matched, even if their branch and body look like a regeneration PR's.
+func (g *Git) isRegenPR(pr PullRequest) bool {
+	if !strings.HasPrefix(pr.HeadRef, regenBranchPrefix) {
+		return false
+	}
+
+	if !strings.EqualFold(pr.HeadRepo, g.cfg.Repository) || !sameBranch(pr.BaseRef, g.cfg.Ref) {
+		return false
+	}
 
-	return branchName, nil, nil
+	return strings.Contains(pr.Body, g.regenPRMarker()) || pr.Title == g.getPRTitle()
+}
+
+// regenPRMarker returns the hidden comment in the body of PRs created by the action used to find them again. It identifies the
+// workflow and base branch so that multiple regeneration workflows in a repo each keep their own PR.
+func (g *Git) regenPRMarker() string {
+	quote := func(value string) string {
+		// Escaped so the value can't end the comment
+		return strings.ReplaceAll(strconv.Quote(value), ">", `\u003e`)
+	}
+
+	return fmt.Sprintf("<!-- speakeasy-sdk-regen workflow=%s base=%s -->", quote(g.cfg.WorkflowName), quote(g.cfg.Ref))
+}
+
+// sameBranch checks whether the refs name the same branch, either may be a full ref or just the branch name
+func sameBranch(a, b string) bool {
+	return strings.TrimPrefix(a, "refs/heads/") == strings.TrimPrefix(b, "refs/heads/")
 }
 
 func (g *Git) FindBranch(branchName string) (string, error) {
@@ -183,16 +354,7 @@ func (g *Git) FindBranch(branchName string) (string, error) {
 		return "", fmt.Errorf("error getting worktree: %w", err)
 	}
 
-	r, err := g.repo.Remote("origin")
-	if err != nil {
-		return "", fmt.Errorf("error getting remote: %w", err)
-	}
-	if err := r.Fetch(&git.FetchOptions{
-		Auth: getGithubAuth(g.accessToken),
-		RefSpecs: []config.RefSpec{
-			config.RefSpec(fmt.Sprintf("refs/heads/%s:refs/heads/%s", branchName, branchName)),
-		},
-	}); err != nil && err != git.NoErrAlreadyUpToDate {
+	if err := g.fetch(config.RefSpec(fmt.Sprintf("refs/heads/%s:refs/heads/%s", branchName, branchName))); err != nil && err != git.NoErrAlreadyUpToDate {
 		return "", fmt.Errorf("error fetching remote: %w", err)
 	}
 
@@ -223,7 +385,12 @@ func (g *Git) FindOrCreateBranch(branchName string) (string, error) {
 		return g.FindBranch(branchName)
 	}
 
-	branchName = fmt.Sprintf("speakeasy-sdk-regen-%d", time.Now().Unix())
+	now := time.Now().Unix()
+	branchName = fmt.Sprintf("%s%d", regenBranchPrefix, now)
+	// A regeneration retried after a merge conflict can create its branch within the same second as the branch it replaces
+	for i := 2; g.branchExists(branchName); i++ {
+		branchName = fmt.Sprintf("%s%d-%d", regenBranchPrefix, now, i)
+	}
 
 	logging.Info("Creating branch %s", branchName)
 
@@ -239,6 +406,11 @@ func (g *Git) FindOrCreateBranch(branchName string) (string, error) {
 	return branchName, nil
 }
 
+func (g *Git) branchExists(branchName string) bool {
+	_, err := g.repo.Reference(plumbing.NewBranchReferenceName(branchName), false)
+	return err == nil
+}
+
 func (g *Git) DeleteBranch(branchName string) error {
 	if g.repo == nil {
 		return fmt.Errorf("repo not cloned")
@@ -246,30 +418,28 @@ func (g *Git) DeleteBranch(branchName string) error {
 
 	logging.Info("Deleting branch %s", branchName)
 
-	r, err := g.repo.Remote("origin")
-	if err != nil {
-		return fmt.Errorf("error getting remote: %w", err)
-	}
-
 	ref := plumbing.NewBranchReferenceName(branchName)
 
-	if err := r.Push(&git.PushOptions{
-		Auth: getGithubAuth(g.accessToken),
-		RefSpecs: []config.RefSpec{
-			config.RefSpec(fmt.Sprintf(":%s", ref.String())),
-		},
-	}); err != nil {
+	if g.plan != nil {
+		g.plan.record("delete remote branch %s", branchName)
+		return nil
+	}
+
+	if err := g.push(config.RefSpec(fmt.Sprintf(":%s", ref.String()))); err != nil {
 		return fmt.Errorf("error deleting branch: %w", err)
 	}
 
 	return nil
 }
 
-func (g *Git) CommitAndPush(openAPIDocVersion, speakeasyVersion string) (string, error) {
+// CommitAndPush commits all changes with a message describing the release, previous is the release history before it
+func (g *Git) CommitAndPush(releaseInfo releases.ReleasesInfo, previous releases.Releases) (string, error) {
 	if g.repo == nil {
 		return "", fmt.Errorf("repo not cloned")
 	}
 
+	data := templates.NewData(g.cfg, releaseInfo, previous)
+
 	w, err := g.repo.Worktree()
 	if err != nil {
 		return "", fmt.Errorf("error getting worktree: %w", err)
@@ -277,136 +447,347 @@ func (g *Git) CommitAndPush(openAPIDocVersion, speakeasyVersion string) (string,
 
 	logging.Info("Commit and pushing changes to git")
 
-	if _, err := w.Add("."); err != nil {
-		return "", fmt.Errorf("error adding changes: %w", err)
+	var commitHashes []plumbing.Hash
+
+	if g.cfg.PerLanguageCommits {
+		commitHashes, err = g.commitPerLanguage(w, data)
+		if err != nil {
+			return "", err
+		}
+	} else {
+		message, err := g.render(templates.CommitMessage, data)
+		if err != nil {
+			return "", err
+		}
+
+		if _, err := w.Add("."); err != nil {
+			return "", fmt.Errorf("error adding changes: %w", err)
+		}
+
+		commitHash, err := g.commit(w, message, true)
+		if err != nil {
+			return "", err
+		}
+		commitHashes = append(commitHashes, commitHash)
+	}
+
+	commitHash := commitHashes[len(commitHashes)-1]
+
+	if g.plan != nil {
+		for _, hash := range commitHashes {
+			g.plan.record("push commit %s", hash.String())
+		}
+		return commitHash.String(), nil
+	}
+
+	head, err := g.repo.Head()
+	if err != nil {
+		return "", fmt.Errorf("error getting head ref: %w", err)
 	}
 
-	commitHash, err := w.Commit(fmt.Sprintf("ci: regenerated with OpenAPI Doc %s, Speakeay CLI %s", openAPIDocVersion, speakeasyVersion), &git.CommitOptions{
+	if err := g.push(config.RefSpec(fmt.Sprintf("%s:%s", head.Name(), head.Name()))); err != nil {
+		return "", fmt.Errorf("error pushing changes: %w", err)
+	}
+
+	return commitHash.String(), nil
+}
+
+// commit commits the staged changes, or all changes to tracked files if all is set, signing the commit if a signing key is configured
+func (g *Git) commit(w *git.Worktree, message string, all bool) (plumbing.Hash, error) {
+	now := time.Now()
+
+	commitHash, err := w.Commit(message, &git.CommitOptions{
 		Author: &object.Signature{
-			Name:  "speakeasybot",
-			Email: "bot@speakeasyapi.dev",
-			When:  time.Now(),
+			Name:  g.cfg.CommitAuthorName,
+			Email: g.cfg.CommitAuthorEmail,
+			When:  now,
 		},
-		All: true,
+		Committer: &object.Signature{
+			Name:  g.cfg.CommitterName,
+			Email: g.cfg.CommitterEmail,
+			When:  now,
+		},
+		All: all,
 	})
 	if err != nil {
-		return "", fmt.Errorf("error committing changes: %w", err)
+		return plumbing.ZeroHash, fmt.Errorf("error committing changes: %w", err)
 	}
 
-	if err := g.repo.Push(&git.PushOptions{
-		Auth: getGithubAuth(g.accessToken),
-	}); err != nil {
-		return "", fmt.Errorf("error pushing changes: %w", err)
+	if g.signer != nil {
+		commitHash, err = g.signCommit(commitHash)
+		if err != nil {
+			return plumbing.ZeroHash, fmt.Errorf("error signing commit: %w", err)
+		}
 	}
 
-	return commitHash.String(), nil
+	return commitHash, nil
+}
+
+// CreateOrUpdatePR creates the PR for the regeneration branch or updates the existing pr, previous is the release history before the release
+func (g *Git) CreateOrUpdatePR(branchName string, releaseInfo releases.ReleasesInfo, previous releases.Releases, previousGenVersion string, changeReport *openapi.ChangeReport, pr *PullRequest) (*PullRequest, error) {
+	title, body, err := g.renderPR(releaseInfo, previous, previousGenVersion, changeReport)
+	if err != nil {
+		return nil, err
+	}
+
+	return g.createOrUpdatePR(branchName, title, body, pr)
 }
 
-func (g *Git) CreateOrUpdatePR(branchName string, releaseInfo releases.ReleasesInfo, previousGenVersion string, pr *github.PullRequest) error {
+// CreateMergeConflictPR opens a PR for a regeneration branch that couldn't be merged in direct mode, describing the conflict in its body.
+// Any PR previously opened for a conflict is closed as superseded.
+func (g *Git) CreateMergeConflictPR(branchName string, releaseInfo releases.ReleasesInfo, previous releases.Releases, previousGenVersion string, changeReport *openapi.ChangeReport, conflict *MergeConflictError) (*PullRequest, error) {
+	_, existing, err := g.FindExistingPR("")
+	if err != nil {
+		return nil, err
+	}
+
+	title, body, err := g.renderPR(releaseInfo, previous, previousGenVersion, changeReport)
+	if err != nil {
+		return nil, err
+	}
+
+	pr, err := g.createOrUpdatePR(branchName, title, body+"\n\n"+conflict.Report(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if existing != nil {
+		logging.Info("Closing superseded PR #%d %s", existing.Number, existing.Title)
+
+		if err := g.provider.ClosePullRequest(*existing, fmt.Sprintf("Superseded by #%d, closing as only the latest regeneration PR is kept up to date.", pr.Number)); err != nil {
+			return nil, fmt.Errorf("failed to close superseded PR #%d: %w", existing.Number, err)
+		}
+	}
+
+	return pr, nil
+}
+
+func (g *Git) renderPR(releaseInfo releases.ReleasesInfo, previous releases.Releases, previousGenVersion string, changeReport *openapi.ChangeReport) (string, string, error) {
 	changelog, err := cli.GetChangelog(releaseInfo.GenerationVersion, previousGenVersion)
 	if err != nil {
-		return fmt.Errorf("failed to get changelog: %w", err)
+		return "", "", fmt.Errorf("failed to get changelog: %w", err)
 	}
+
+	data := templates.NewData(g.cfg, releaseInfo, previous)
+	data.ChangeReport = changeReport
 	if strings.TrimSpace(changelog) != "" {
-		changelog = "\n\n\n## CHANGELOG\n\n" + changelog
+		data.Changelog = changelog
+	}
+
+	title, err := g.render(templates.PRTitle, data)
+	if err != nil {
+		return "", "", err
 	}
 
-	body := fmt.Sprintf(`# Generated by Speakeasy CLI
-Based on:
-- OpenAPI Doc %s %s
-- Speakeasy CLI %s (%s) https://github.com/speakeasy-api/speakeasy%s`, releaseInfo.DocVersion, releaseInfo.DocLocation, releaseInfo.SpeakeasyVersion, releaseInfo.GenerationVersion, changelog)
+	body, err := g.render(templates.PRBody, data)
+	if err != nil {
+		return "", "", err
+	}
+
+	// The marker identifies the PR as a regeneration PR regardless of how the title and body are templated
+	return title, g.regenPRMarker() + "\n" + body, nil
+}
+
+func (g *Git) createOrUpdatePR(branchName, title, body string, pr *PullRequest) (*PullRequest, error) {
+	var err error
+
+	metadata := g.getPRMetadata()
+	// Only the metadata applied by a previous run is removed when no longer configured, anything added to the PR by other means is left in place
+	previousMetadata := PullRequestMetadata{}
 
 	if pr != nil {
 		logging.Info("Updating PR")
 
-		pr.Body = github.String(body)
-		pr, _, err = g.client.PullRequests.Edit(context.Background(), os.Getenv("GITHUB_REPOSITORY_OWNER"), getRepo(), pr.GetNumber(), pr)
+		previousMetadata = parsePRMetadata(pr.Body)
+
+		pr.Title = title
+		pr.Body = body + prMetadataMarker(metadata)
+		pr.Draft = g.cfg.PRDraft
+		pr, err = g.provider.UpdatePullRequest(*pr)
 		if err != nil {
-			return fmt.Errorf("failed to update PR: %w", err)
+			return nil, fmt.Errorf("failed to update PR: %w", err)
 		}
 	} else {
 		logging.Info("Creating PR")
 
-		fmt.Println(body, branchName, getPRTitle(), environment.GetRef())
+		fmt.Println(body, branchName, title, g.cfg.Ref)
 
-		pr, _, err = g.client.PullRequests.Create(context.Background(), os.Getenv("GITHUB_REPOSITORY_OWNER"), getRepo(), &github.NewPullRequest{
-			Title:               github.String(getPRTitle()),
-			Body:                github.String(body),
-			Head:                github.String(branchName),
-			Base:                github.String(environment.GetRef()),
-			MaintainerCanModify: github.Bool(true),
+		pr, err = g.provider.CreatePullRequest(PullRequest{
+			Title:   title,
+			Body:    body + prMetadataMarker(metadata),
+			HeadRef: branchName,
+			BaseRef: g.cfg.Ref,
+			Draft:   g.cfg.PRDraft,
 		})
 		if err != nil {
-			return fmt.Errorf("failed to create PR: %w", err)
+			return nil, fmt.Errorf("failed to create PR: %w", err)
 		}
 	}
 
-	url := ""
-	if pr.URL != nil {
-		url = *pr.HTMLURL
+	if stale := metadata.Stale(previousMetadata); !metadata.IsEmpty() || !stale.IsEmpty() {
+		if err := g.provider.SetPullRequestMetadata(*pr, metadata, stale); err != nil {
+			return nil, fmt.Errorf("failed to set PR metadata: %w", err)
+		}
 	}
 
-	logging.Info("PR: %s", url)
+	logging.Info("PR: %s", pr.URL)
 
-	return nil
+	return pr, nil
 }
 
-func (g *Git) MergeBranch(branchName string) (string, error) {
-	if g.repo == nil {
-		return "", fmt.Errorf("repo not cloned")
+// prMetadataMarker returns a hidden comment recording the metadata applied to the PR, so it can be removed once no longer configured
+func prMetadataMarker(metadata PullRequestMetadata) string {
+	if metadata.IsEmpty() {
+		return ""
 	}
 
-	w, err := g.repo.Worktree()
+	data, err := json.Marshal(metadata)
 	if err != nil {
-		return "", fmt.Errorf("error getting worktree: %w", err)
+		return ""
 	}
 
-	logging.Info("Merging branch %s", branchName)
+	return "\n" + prMetadataMarkerPrefix + string(data) + prMetadataMarkerSuffix
+}
 
-	// Checkout target branch
-	if err := w.Checkout(&git.CheckoutOptions{
-		Branch: plumbing.ReferenceName(environment.GetRef()),
-		Create: false,
-	}); err != nil {
-		return "", fmt.Errorf("error checking out branch: %w", err)
+// parsePRMetadata returns the metadata recorded in the body of the PR by prMetadataMarker, empty if there isn't any
+func parsePRMetadata(body string) PullRequestMetadata {
+	metadata := PullRequestMetadata{}
+
+	start := strings.Index(body, prMetadataMarkerPrefix)
+	if start < 0 {
+		return metadata
 	}
+	data := body[start+len(prMetadataMarkerPrefix):]
 
-	output, err := runGitCommand("merge", branchName)
-	if err != nil {
-		return "", fmt.Errorf("error merging branch: %w", err)
+	end := strings.Index(data, prMetadataMarkerSuffix)
+	if end < 0 {
+		return metadata
 	}
 
-	logging.Debug("Merge output: %s", output)
+	if err := json.Unmarshal([]byte(data[:end]), &metadata); err != nil {
+		logging.Debug("failed to parse PR metadata: %v", err)
+		return PullRequestMetadata{}
+	}
 
-	headRef, err := g.repo.Head()
-	if err != nil {
-		return "", fmt.Errorf("error getting head ref: %w", err)
+	return metadata
+}
+
+// SyncAutoMerge enables auto-merge of the PR, unless major bumps must be merged manually and the release contains one,
+// in which case auto-merge enabled by a previous regeneration is disabled. Draft PRs can't be auto-merged so are left as they are.
+// Returns whether auto-merge is enabled.
+func (g *Git) SyncAutoMerge(pr *PullRequest, bump releases.Bump) (bool, error) {
+	if g.cfg.AutoMergeSkipMajor && bump == releases.BumpMajor {
+		logging.Info("Not enabling auto-merge as the release contains a major version bump, the PR needs to be merged manually")
+
+		if err := g.provider.DisableAutoMerge(*pr); err != nil {
+			logging.Debug("failed to disable auto-merge: %v", err)
+		}
+
+		return false, nil
 	}
 
-	if err := g.repo.Push(&git.PushOptions{
-		Auth: getGithubAuth(g.accessToken),
-	}); err != nil {
-		return "", fmt.Errorf("error pushing changes: %w", err)
+	if pr.Draft {
+		logging.Info("Not enabling auto-merge as PR #%d is a draft", pr.Number)
+
+		return false, nil
+	}
+
+	logging.Info("Enabling auto-merge of PR #%d using %s for a %s version bump", pr.Number, g.cfg.AutoMergeMethod, bump)
+
+	if err := g.provider.EnableAutoMerge(*pr, g.cfg.AutoMergeMethod); err != nil {
+		return false, fmt.Errorf("failed to enable auto-merge: %w", err)
+	}
+
+	return true, nil
+}
+
+func (g *Git) getPRMetadata() PullRequestMetadata {
+	return PullRequestMetadata{
+		Labels:        g.cfg.PRLabels,
+		Reviewers:     g.cfg.PRReviewers,
+		TeamReviewers: g.cfg.PRTeamReviewers,
+		Assignees:     g.cfg.PRAssignees,
+		Milestone:     g.cfg.PRMilestone,
+	}
+}
+
+// GetSpeakeasyReleases lists all releases of the Speakeasy CLI, used to resolve version constraints
+func (g *Git) GetSpeakeasyReleases() ([]cli.Release, error) {
+	opts := &github.ListOptions{PerPage: 100}
+
+	releases := []cli.Release{}
+
+	for {
+		var page []*github.RepositoryRelease
+		var resp *github.Response
+		err := defaultRetryPolicy.do("list speakeasy cli releases", func(int) error {
+			var err error
+			page, resp, err = g.client.Repositories.ListReleases(context.Background(), "speakeasy-api", "speakeasy", opts)
+			return err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get speakeasy cli releases: %w", err)
+		}
+
+		for _, release := range page {
+			releases = append(releases, cli.Release{
+				Tag:        release.GetTagName(),
+				Prerelease: release.GetPrerelease(),
+				Draft:      release.GetDraft(),
+			})
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	return releases, nil
+}
+
+// GetLatestSpeakeasyRelease returns the tag of the latest release of the Speakeasy CLI
+func (g *Git) GetLatestSpeakeasyRelease() (string, error) {
+	var release *github.RepositoryRelease
+	err := defaultRetryPolicy.do("get latest speakeasy cli release", func(int) error {
+		var err error
+		release, _, err = g.client.Repositories.GetLatestRelease(context.Background(), "speakeasy-api", "speakeasy")
+		return err
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to get latest speakeasy cli release: %w", err)
 	}
 
-	return headRef.Hash().String(), nil
+	return release.GetTagName(), nil
 }
 
-func (g *Git) GetLatestTag() (string, error) {
-	tags, _, err := g.client.Repositories.ListTags(context.Background(), "speakeasy-api", "speakeasy", nil)
+// GetReleaseAssets returns the download URLs of the assets of a Speakeasy CLI release keyed by asset name
+func (g *Git) GetReleaseAssets(tag string) (map[string]string, error) {
+	var release *github.RepositoryRelease
+	err := defaultRetryPolicy.do(fmt.Sprintf("get speakeasy cli release %s", tag), func(int) error {
+		var err error
+		release, _, err = g.client.Repositories.GetReleaseByTag(context.Background(), "speakeasy-api", "speakeasy", tag)
+		return err
+	})
 	if err != nil {
-		return "", fmt.Errorf("failed to get speakeasy cli tags: %w", err)
+		return nil, fmt.Errorf("failed to get speakeasy cli release %s: %w", tag, err)
 	}
 
-	if len(tags) == 0 {
-		return "", fmt.Errorf("no speakeasy cli tags found")
+	assets := map[string]string{}
+	for _, asset := range release.Assets {
+		assets[asset.GetName()] = asset.GetBrowserDownloadURL()
 	}
 
-	return tags[0].GetName(), nil
+	return assets, nil
 }
 
-func (g *Git) GetCommitedFiles() ([]string, error) {
-	path := environment.GetWorkflowEventPayloadPath()
+type workflowEventPayload struct {
+	After  string `json:"after"`
+	Before string `json:"before"`
+}
+
+func (g *Git) getWorkflowEventPayload() (*workflowEventPayload, error) {
+	path := g.cfg.EventPath
 
 	if path == "" {
 		return nil, fmt.Errorf("no workflow event payload path")
@@ -417,10 +798,7 @@ func (g *Git) GetCommitedFiles() ([]string, error) {
 		return nil, fmt.Errorf("failed to read workflow event payload: %w", err)
 	}
 
-	var payload struct {
-		After  string `json:"after"`
-		Before string `json:"before"`
-	}
+	var payload workflowEventPayload
 
 	if err := json.Unmarshal(data, &payload); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal workflow event payload: %w", err)
@@ -430,6 +808,42 @@ func (g *Git) GetCommitedFiles() ([]string, error) {
 		return nil, fmt.Errorf("no commit hash found in workflow event payload")
 	}
 
+	return &payload, nil
+}
+
+// GetFileBeforePush returns the contents of a file in the repo as it was before the commits that triggered the workflow were pushed
+func (g *Git) GetFileBeforePush(filePath string) (string, error) {
+	if g.repo == nil {
+		return "", fmt.Errorf("repo not cloned")
+	}
+
+	payload, err := g.getWorkflowEventPayload()
+	if err != nil {
+		return "", err
+	}
+
+	beforeCommit, err := g.repo.CommitObject(plumbing.NewHash(payload.Before))
+	if err != nil {
+		return "", fmt.Errorf("failed to get before commit object: %w", err)
+	}
+
+	file, err := beforeCommit.File(filepath.ToSlash(path.Clean(filePath)))
+	if errors.Is(err, object.ErrFileNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get file %s from before commit: %w", filePath, err)
+	}
+
+	return file.Contents()
+}
+
+func (g *Git) GetCommitedFiles() ([]string, error) {
+	payload, err := g.getWorkflowEventPayload()
+	if err != nil {
+		return nil, err
+	}
+
 	beforeCommit, err := g.repo.CommitObject(plumbing.NewHash(payload.Before))
 	if err != nil {
 		return nil, fmt.Errorf("failed to get before commit object: %w", err)
@@ -474,28 +888,155 @@ func (g *Git) GetCommitedFiles() ([]string, error) {
 	return files, nil
 }
 
-func getGithubAuth(accessToken string) *gitHttp.BasicAuth {
-	return &gitHttp.BasicAuth{
-		Username: "gen",
-		Password: accessToken,
+// push pushes the refs to the remote, retrying on transient failures
+func (g *Git) push(refSpecs ...config.RefSpec) error {
+	return defaultRetryPolicy.do(fmt.Sprintf("push %s", joinRefSpecs(refSpecs)), func(attempt int) error {
+		auth, err := g.getAuth()
+		if err != nil {
+			return err
+		}
+
+		err = g.repo.Push(&git.PushOptions{
+			Auth:     auth,
+			CABundle: []byte(g.cfg.CABundle),
+			RefSpecs: refSpecs,
+		})
+		// A previous attempt may have pushed the refs before failing
+		if attempt > 1 && err == git.NoErrAlreadyUpToDate {
+			return nil
+		}
+
+		return err
+	})
+}
+
+// fetch fetches the refs from the remote, retrying on transient failures. Returns git.NoErrAlreadyUpToDate if there was nothing to fetch.
+func (g *Git) fetch(refSpecs ...config.RefSpec) error {
+	return defaultRetryPolicy.do(fmt.Sprintf("fetch %s", joinRefSpecs(refSpecs)), func(int) error {
+		auth, err := g.getAuth()
+		if err != nil {
+			return err
+		}
+
+		return g.repo.Fetch(&git.FetchOptions{
+			Auth:     auth,
+			CABundle: []byte(g.cfg.CABundle),
+			RefSpecs: refSpecs,
+		})
+	})
+}
+
+// listRemoteRefs lists the refs on the remote, retrying on transient failures
+func (g *Git) listRemoteRefs() ([]*plumbing.Reference, error) {
+	r, err := g.repo.Remote("origin")
+	if err != nil {
+		return nil, fmt.Errorf("error getting remote: %w", err)
 	}
+
+	var refs []*plumbing.Reference
+	err = defaultRetryPolicy.do("list remote refs", func(int) error {
+		auth, err := g.getAuth()
+		if err != nil {
+			return err
+		}
+
+		refs, err = r.List(&git.ListOptions{
+			Auth:     auth,
+			CABundle: []byte(g.cfg.CABundle),
+		})
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listing remote refs: %w", err)
+	}
+
+	return refs, nil
+}
+
+func joinRefSpecs(refSpecs []config.RefSpec) string {
+	out := []string{}
+	for _, refSpec := range refSpecs {
+		out = append(out, refSpec.String())
+	}
+
+	return strings.Join(out, " ")
 }
 
-func getRepo() string {
-	repoPath := os.Getenv("GITHUB_REPOSITORY")
-	parts := strings.Split(repoPath, "/")
-	return parts[len(parts)-1]
+// getAuth returns the credentials for the git remote, minting a new GitHub App installation token if the current one is about to expire
+func (g *Git) getAuth() (*gitHttp.BasicAuth, error) {
+	token, err := g.tokenSource.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	// Installation tokens are only accepted with this username, access tokens accept any
+	username := "gen"
+	if g.cfg.UseGithubApp() {
+		username = "x-access-token"
+	}
+
+	return &gitHttp.BasicAuth{
+		Username: username,
+		Password: token.AccessToken,
+	}, nil
 }
 
-const speakeasyPRTitle = "chore: speakeasy sdk regeneration - "
+const (
+	speakeasyPRTitle  = "chore: speakeasy sdk regeneration - "
+	regenBranchPrefix = "speakeasy-sdk-regen-"
+	// prMetadataMarkerPrefix and prMetadataMarkerSuffix surround the metadata applied to the PR in a hidden comment in its body
+	prMetadataMarkerPrefix = "<!-- speakeasy-sdk-regen-metadata "
+	prMetadataMarkerSuffix = " -->"
+)
 
-func getPRTitle() string {
-	return speakeasyPRTitle + environment.GetWorkflowName()
+// getPRTitle returns the title of PRs created before PR titles could be templated, used to match PRs created by older versions of the action
+func (g *Git) getPRTitle() string {
+	return speakeasyPRTitle + g.cfg.WorkflowName
+}
+
+// Templates returns the templates in the cloned repo's templates directory, loading them on first use
+func (g *Git) Templates() (*templates.Templates, error) {
+	if g.templates == nil {
+		t, err := templates.Load(filepath.Join(environment.GetBaseDir(), "repo", g.cfg.TemplatesDir), g.cfg.ConventionalCommits)
+		if err != nil {
+			return nil, err
+		}
+
+		g.templates = t
+	}
+
+	return g.templates, nil
+}
+
+func (g *Git) render(name templates.Name, data templates.Data) (string, error) {
+	t, err := g.Templates()
+	if err != nil {
+		return "", err
+	}
+
+	return t.Render(name, data)
 }
 
 func runGitCommand(args ...string) (string, error) {
+	return runGitCommandWithEnv(nil, args...)
+}
+
+// runGitCommandAsCommitter runs a git command that creates commits, such as a merge or rebase, as the configured author and committer
+func (g *Git) runGitCommandAsCommitter(args ...string) (string, error) {
+	return runGitCommandWithEnv([]string{
+		"GIT_AUTHOR_NAME=" + g.cfg.CommitAuthorName,
+		"GIT_AUTHOR_EMAIL=" + g.cfg.CommitAuthorEmail,
+		"GIT_COMMITTER_NAME=" + g.cfg.CommitterName,
+		"GIT_COMMITTER_EMAIL=" + g.cfg.CommitterEmail,
+	}, args...)
+}
+
+func runGitCommandWithEnv(env []string, args ...string) (string, error) {
 	cmd := exec.Command("git", args...)
 	cmd.Dir = filepath.Join(environment.GetBaseDir(), "repo")
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
 	var outb, errb bytes.Buffer
 	cmd.Stdout = &outb
 	cmd.Stderr = &errb
@@ -0,0 +1,230 @@
+package git
+
+import (
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"github.com/speakeasy-api/sdk-generation-action/internal/environment"
+	"github.com/speakeasy-api/sdk-generation-action/pkg/releases"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestGit(t *testing.T, provider Provider) *Git {
+	t.Helper()
+
+	repo, err := git.Init(memory.NewStorage(), nil)
+	require.NoError(t, err)
+
+	return &Git{
+		cfg:      &environment.Config{WorkflowName: "generate", Repository: "owner/repo", Ref: "refs/heads/main"},
+		repo:     repo,
+		provider: provider,
+	}
+}
+
+// testRegenMarker is the marker of PRs created for newTestGit's workflow and base branch
+const testRegenMarker = `<!-- speakeasy-sdk-regen workflow="generate" base="refs/heads/main" -->`
+
+func TestFindExistingPR_MatchesMarkerAcrossPages(t *testing.T) {
+	prs := []PullRequest{}
+	for i := 1; i <= 120; i++ {
+		prs = append(prs, PullRequest{Number: i, Title: "unrelated", HeadRef: "feature", HeadRepo: "owner/repo", BaseRef: "main"})
+	}
+	prs = append(prs, PullRequest{Number: 121, Title: "chore: custom title", Body: testRegenMarker + "\nbody", HeadRef: "speakeasy-sdk-regen-1", HeadRepo: "owner/repo", BaseRef: "main"})
+
+	p := &recordingProvider{prs: prs}
+	g := newTestGit(t, p)
+
+	branchName, pr, err := g.FindExistingPR("")
+	require.NoError(t, err)
+	require.NotNil(t, pr)
+	assert.Equal(t, 121, pr.Number)
+	assert.Equal(t, "speakeasy-sdk-regen-1", branchName)
+	assert.Equal(t, []string{"list"}, p.calls)
+}
+
+func TestFindExistingPR_MatchesLegacyTitle(t *testing.T) {
+	p := &recordingProvider{prs: []PullRequest{
+		{Number: 1, Title: "chore: speakeasy sdk regeneration - generate", HeadRef: "speakeasy-sdk-regen-1", HeadRepo: "owner/repo", BaseRef: "main"},
+	}}
+	g := newTestGit(t, p)
+
+	branchName, pr, err := g.FindExistingPR("speakeasy-sdk-regen-1")
+	require.NoError(t, err)
+	require.NotNil(t, pr)
+	assert.Equal(t, "speakeasy-sdk-regen-1", branchName)
+}
+
+func TestFindExistingPR_IgnoresOtherPRs(t *testing.T) {
+	tests := []struct {
+		name string
+		pr   PullRequest
+	}{
+		{
+			name: "other branch",
+			pr:   PullRequest{Number: 1, Title: "chore: speakeasy sdk regeneration - generate", Body: testRegenMarker, HeadRef: "my-feature", HeadRepo: "owner/repo", BaseRef: "main"},
+		},
+		{
+			name: "other workflow",
+			pr:   PullRequest{Number: 1, Body: `<!-- speakeasy-sdk-regen workflow="generate-v2" base="refs/heads/main" -->`, HeadRef: "speakeasy-sdk-regen-1", HeadRepo: "owner/repo", BaseRef: "main"},
+		},
+		{
+			name: "other base branch",
+			pr:   PullRequest{Number: 1, Title: "chore: speakeasy sdk regeneration - generate", Body: testRegenMarker, HeadRef: "speakeasy-sdk-regen-1", HeadRepo: "owner/repo", BaseRef: "v2"},
+		},
+		{
+			name: "fork",
+			pr:   PullRequest{Number: 1, Title: "chore: speakeasy sdk regeneration - generate", Body: testRegenMarker, HeadRef: "speakeasy-sdk-regen-1", HeadRepo: "someone/repo", BaseRef: "main"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &recordingProvider{prs: []PullRequest{tt.pr}}
+			g := newTestGit(t, p)
+
+			branchName, pr, err := g.FindExistingPR("")
+			require.NoError(t, err)
+			assert.Nil(t, pr)
+			assert.Empty(t, branchName)
+			assert.Equal(t, []string{"list"}, p.calls, "the PR should not be closed")
+		})
+	}
+}
+
+func TestFindExistingPR_ClosesDuplicates(t *testing.T) {
+	p := &recordingProvider{prs: []PullRequest{
+		{Number: 3, Body: testRegenMarker, HeadRef: "speakeasy-sdk-regen-3", HeadRepo: "owner/repo", BaseRef: "main"},
+		{Number: 7, Body: testRegenMarker, HeadRef: "speakeasy-sdk-regen-7", HeadRepo: "owner/repo", BaseRef: "refs/heads/main"},
+		{Number: 5, Title: "chore: speakeasy sdk regeneration - generate", HeadRef: "speakeasy-sdk-regen-5", HeadRepo: "owner/repo", BaseRef: "main"},
+		{Number: 9, Body: testRegenMarker, HeadRef: "speakeasy-sdk-regen-9", HeadRepo: "someone/repo", BaseRef: "main"},
+	}}
+	g := newTestGit(t, p)
+
+	branchName, pr, err := g.FindExistingPR("")
+	require.NoError(t, err)
+	require.NotNil(t, pr)
+	assert.Equal(t, 7, pr.Number)
+	assert.Equal(t, "speakeasy-sdk-regen-7", branchName)
+	assert.Equal(t, []string{
+		"list",
+		"close #5: Superseded by #7, closing as only the latest regeneration PR is kept up to date.",
+		"close #3: Superseded by #7, closing as only the latest regeneration PR is kept up to date.",
+	}, p.calls)
+}
+
+func TestFindExistingPR_DifferentBranch_Error(t *testing.T) {
+	p := &recordingProvider{prs: []PullRequest{
+		{Number: 1, Body: testRegenMarker, HeadRef: "speakeasy-sdk-regen-1", HeadRepo: "owner/repo", BaseRef: "main"},
+	}}
+	g := newTestGit(t, p)
+
+	_, _, err := g.FindExistingPR("speakeasy-sdk-regen-2")
+	assert.ErrorContains(t, err, "existing PR has different branch name")
+}
+
+func TestRegenPRMarker(t *testing.T) {
+	g := newTestGit(t, &recordingProvider{})
+	assert.Equal(t, testRegenMarker, g.regenPRMarker())
+
+	g.cfg.WorkflowName = `sdk "gen" -->`
+	assert.Equal(t, `<!-- speakeasy-sdk-regen workflow="sdk \"gen\" --\u003e" base="refs/heads/main" -->`, g.regenPRMarker())
+}
+
+func TestSyncAutoMerge(t *testing.T) {
+	tests := []struct {
+		name        string
+		skipMajor   bool
+		draft       bool
+		bump        releases.Bump
+		wantEnabled bool
+		wantCalls   []string
+	}{
+		{
+			name:        "enabled",
+			bump:        releases.BumpMajor,
+			wantEnabled: true,
+			wantCalls:   []string{"auto-merge #3: squash"},
+		},
+		{
+			name:        "minor bump with major skipped",
+			skipMajor:   true,
+			bump:        releases.BumpMinor,
+			wantEnabled: true,
+			wantCalls:   []string{"auto-merge #3: squash"},
+		},
+		{
+			name:      "major bump with major skipped",
+			skipMajor: true,
+			bump:      releases.BumpMajor,
+			wantCalls: []string{"disable auto-merge #3"},
+		},
+		{
+			name:  "draft",
+			draft: true,
+			bump:  releases.BumpPatch,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &recordingProvider{}
+			g := newTestGit(t, p)
+			g.cfg.AutoMergeMethod = environment.MergeMethodSquash
+			g.cfg.AutoMergeSkipMajor = tt.skipMajor
+
+			enabled, err := g.SyncAutoMerge(&PullRequest{Number: 3, Draft: tt.draft}, tt.bump)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantEnabled, enabled)
+			assert.Equal(t, tt.wantCalls, p.calls)
+		})
+	}
+}
+
+func TestCreateOrUpdatePR_SyncsMetadata(t *testing.T) {
+	p := &recordingProvider{}
+	g := newTestGit(t, p)
+	g.cfg.PRLabels = []string{"sdk", "automated"}
+	g.cfg.PRAssignees = []string{"hubot"}
+	g.cfg.PRMilestone = "v1"
+	g.cfg.PRDraft = true
+
+	pr, err := g.createOrUpdatePR("speakeasy-sdk-regen-1", "title", testRegenMarker+"\nbody", nil)
+	require.NoError(t, err)
+	assert.True(t, pr.Draft)
+	assert.Equal(t, PullRequestMetadata{Labels: []string{"sdk", "automated"}, Assignees: []string{"hubot"}, Milestone: "v1"}, parsePRMetadata(pr.Body))
+
+	// Only the metadata applied by the previous run and no longer configured is removed
+	pr.Number = 3
+	g.cfg.PRLabels = []string{"sdk", "regenerated"}
+	g.cfg.PRMilestone = ""
+	g.cfg.PRDraft = false
+
+	pr, err = g.createOrUpdatePR("speakeasy-sdk-regen-1", "title", testRegenMarker+"\nbody", pr)
+	require.NoError(t, err)
+	assert.False(t, pr.Draft)
+	assert.Equal(t, PullRequestMetadata{Labels: []string{"sdk", "regenerated"}, Assignees: []string{"hubot"}}, parsePRMetadata(pr.Body))
+
+	assert.Equal(t, []string{
+		"create",
+		"metadata #0: labels=sdk,automated assignees=hubot milestone=v1 removing ",
+		"update #3 draft=false",
+		"metadata #3: labels=sdk,regenerated assignees=hubot removing labels=automated milestone=v1",
+	}, p.calls)
+}
+
+func TestParsePRMetadata(t *testing.T) {
+	metadata := PullRequestMetadata{Labels: []string{"sdk", "<!-- -->"}, TeamReviewers: []string{"sdk-owners"}}
+
+	assert.Equal(t, metadata, parsePRMetadata(testRegenMarker+"\nbody"+prMetadataMarker(metadata)))
+	assert.Equal(t, PullRequestMetadata{}, parsePRMetadata(testRegenMarker+"\nbody"))
+	assert.Equal(t, PullRequestMetadata{}, parsePRMetadata("body\n"+prMetadataMarkerPrefix+"{invalid"+prMetadataMarkerSuffix))
+	assert.Empty(t, prMetadataMarker(PullRequestMetadata{}))
+}
+
+func TestSameHost(t *testing.T) {
+	assert.True(t, sameHost("", ""))
+	assert.True(t, sameHost("", "https://api.github.com/"))
+	assert.True(t, sameHost("https://ghes.example.com/api/v3", "https://ghes.example.com/api/v3/"))
+	assert.False(t, sameHost("https://ghes.example.com/api/v3", ""))
+}
@@ -0,0 +1,420 @@
+package git
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/speakeasy-api/sdk-generation-action/internal/environment"
+)
+
+const (
+	giteaPageSize = 50
+	// giteaDraftPrefix marks a PR as a work in progress, Gitea's equivalent of a draft PR
+	giteaDraftPrefix = "WIP: "
+)
+
+type giteaProvider struct {
+	apiURL      string
+	accessToken string
+	owner       string
+	repo        string
+	client      *http.Client
+}
+
+var _ Provider = (*giteaProvider)(nil)
+
+type giteaBranch struct {
+	Ref  string    `json:"ref"`
+	Repo giteaRepo `json:"repo"`
+}
+
+type giteaRepo struct {
+	FullName string `json:"full_name"`
+}
+
+type giteaLabel struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+type giteaUser struct {
+	Login string `json:"login"`
+}
+
+type giteaIssue struct {
+	Assignees []giteaUser `json:"assignees"`
+}
+
+type giteaMilestone struct {
+	ID    int64  `json:"id"`
+	Title string `json:"title"`
+}
+
+type giteaRelease struct {
+	TagName         string `json:"tag_name"`
+	TargetCommitish string `json:"target_commitish"`
+	Name            string `json:"name"`
+	Body            string `json:"body"`
+}
+
+type giteaPullRequest struct {
+	Number  int         `json:"number"`
+	Title   string      `json:"title"`
+	Body    string      `json:"body"`
+	HTMLURL string      `json:"html_url"`
+	Head    giteaBranch `json:"head"`
+	Base    giteaBranch `json:"base"`
+}
+
+// giteaError is returned for error responses from the Gitea API
+type giteaError struct {
+	Method     string
+	URL        string
+	StatusCode int
+	Header     http.Header
+	Body       string
+}
+
+func (e *giteaError) Error() string {
+	return fmt.Sprintf("%s %s: %d %s", e.Method, e.URL, e.StatusCode, e.Body)
+}
+
+func newGiteaProvider(client *http.Client, apiURL, accessToken, owner, repo string) *giteaProvider {
+	return &giteaProvider{
+		apiURL:      strings.TrimSuffix(apiURL, "/"),
+		accessToken: accessToken,
+		owner:       owner,
+		repo:        repo,
+		client:      client,
+	}
+}
+
+func (p *giteaProvider) ListOpenPullRequests() ([]PullRequest, error) {
+	out := []PullRequest{}
+
+	for page := 1; ; page++ {
+		var prs []giteaPullRequest
+		if err := p.do(http.MethodGet, fmt.Sprintf("/pulls?state=open&page=%d&limit=%d", page, giteaPageSize), nil, &prs); err != nil {
+			return nil, fmt.Errorf("error getting pull requests: %w", err)
+		}
+
+		for _, pr := range prs {
+			out = append(out, pr.toPullRequest())
+		}
+
+		if len(prs) < giteaPageSize {
+			break
+		}
+	}
+
+	return out, nil
+}
+
+func (p *giteaProvider) CreatePullRequest(pr PullRequest) (*PullRequest, error) {
+	req := map[string]string{
+		"title": giteaTitle(pr),
+		"body":  pr.Body,
+		"head":  pr.HeadRef,
+		"base":  pr.BaseRef,
+	}
+
+	var created giteaPullRequest
+	if err := p.do(http.MethodPost, "/pulls", req, &created); err != nil {
+		return nil, err
+	}
+
+	out := created.toPullRequest()
+	return &out, nil
+}
+
+func (p *giteaProvider) UpdatePullRequest(pr PullRequest) (*PullRequest, error) {
+	req := map[string]string{
+		"title": giteaTitle(pr),
+		"body":  pr.Body,
+	}
+
+	var updated giteaPullRequest
+	if err := p.do(http.MethodPatch, fmt.Sprintf("/pulls/%d", pr.Number), req, &updated); err != nil {
+		return nil, err
+	}
+
+	out := updated.toPullRequest()
+	return &out, nil
+}
+
+func (p *giteaProvider) ClosePullRequest(pr PullRequest, comment string) error {
+	if err := p.do(http.MethodPost, fmt.Sprintf("/issues/%d/comments", pr.Number), map[string]string{"body": comment}, nil); err != nil {
+		return err
+	}
+
+	return p.do(http.MethodPatch, fmt.Sprintf("/pulls/%d", pr.Number), map[string]string{"state": "closed"}, nil)
+}
+
+func (p *giteaProvider) SetPullRequestMetadata(pr PullRequest, metadata, stale PullRequestMetadata) error {
+	if len(metadata.Labels) > 0 || len(stale.Labels) > 0 {
+		labelIDs, err := p.getLabelIDs()
+		if err != nil {
+			return err
+		}
+
+		for _, name := range stale.Labels {
+			// A stale label deleted from the repo has already been removed from the PR
+			id, ok := labelIDs[name]
+			if !ok {
+				continue
+			}
+
+			if err := p.do(http.MethodDelete, fmt.Sprintf("/issues/%d/labels/%d", pr.Number, id), nil, nil); err != nil {
+				return fmt.Errorf("failed to remove label %s: %w", name, err)
+			}
+		}
+
+		if len(metadata.Labels) > 0 {
+			ids := []int64{}
+			for _, name := range metadata.Labels {
+				id, ok := labelIDs[name]
+				if !ok {
+					return fmt.Errorf("label %q not found", name)
+				}
+				ids = append(ids, id)
+			}
+
+			if err := p.do(http.MethodPost, fmt.Sprintf("/issues/%d/labels", pr.Number), map[string][]int64{"labels": ids}, nil); err != nil {
+				return fmt.Errorf("failed to add labels: %w", err)
+			}
+		}
+	}
+
+	if len(metadata.Assignees) > 0 || len(stale.Assignees) > 0 || metadata.Milestone != "" || stale.Milestone != "" {
+		req := map[string]interface{}{}
+		if len(metadata.Assignees) > 0 || len(stale.Assignees) > 0 {
+			// Gitea replaces the assignees of the PR, so the current ones are kept unless stale
+			var issue giteaIssue
+			if err := p.do(http.MethodGet, fmt.Sprintf("/issues/%d", pr.Number), nil, &issue); err != nil {
+				return fmt.Errorf("error getting assignees: %w", err)
+			}
+
+			current := []string{}
+			for _, assignee := range issue.Assignees {
+				current = append(current, assignee.Login)
+			}
+
+			assignees := missing(current, stale.Assignees)
+			req["assignees"] = append(assignees, missing(metadata.Assignees, assignees)...)
+		}
+		if metadata.Milestone != "" {
+			id, err := p.findMilestone(metadata.Milestone)
+			if err != nil {
+				return err
+			}
+			req["milestone"] = id
+		} else if stale.Milestone != "" {
+			// A milestone of 0 removes the PR from its milestone
+			req["milestone"] = 0
+		}
+
+		if err := p.do(http.MethodPatch, fmt.Sprintf("/issues/%d", pr.Number), req, nil); err != nil {
+			return fmt.Errorf("failed to set assignees and milestone: %w", err)
+		}
+	}
+
+	if len(stale.Reviewers) > 0 || len(stale.TeamReviewers) > 0 {
+		req := map[string][]string{
+			"reviewers":      stale.Reviewers,
+			"team_reviewers": stale.TeamReviewers,
+		}
+
+		if err := p.do(http.MethodDelete, fmt.Sprintf("/pulls/%d/requested_reviewers", pr.Number), req, nil); err != nil {
+			return fmt.Errorf("failed to remove requested reviewers: %w", err)
+		}
+	}
+
+	if len(metadata.Reviewers) > 0 || len(metadata.TeamReviewers) > 0 {
+		req := map[string][]string{
+			"reviewers":      metadata.Reviewers,
+			"team_reviewers": metadata.TeamReviewers,
+		}
+
+		if err := p.do(http.MethodPost, fmt.Sprintf("/pulls/%d/requested_reviewers", pr.Number), req, nil); err != nil {
+			return fmt.Errorf("failed to request reviewers: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (p *giteaProvider) EnableAutoMerge(pr PullRequest, method environment.MergeMethod) error {
+	req := map[string]interface{}{
+		"Do":                        string(method),
+		"merge_when_checks_succeed": true,
+	}
+
+	return p.do(http.MethodPost, fmt.Sprintf("/pulls/%d/merge", pr.Number), req, nil)
+}
+
+func (p *giteaProvider) DisableAutoMerge(pr PullRequest) error {
+	return p.do(http.MethodDelete, fmt.Sprintf("/pulls/%d/merge", pr.Number), nil, nil)
+}
+
+// getLabelIDs returns the ids of the repo's labels by name as Gitea only accepts label ids
+func (p *giteaProvider) getLabelIDs() (map[string]int64, error) {
+	byName := map[string]int64{}
+
+	for page := 1; ; page++ {
+		var labels []giteaLabel
+		if err := p.do(http.MethodGet, fmt.Sprintf("/labels?page=%d&limit=%d", page, giteaPageSize), nil, &labels); err != nil {
+			return nil, fmt.Errorf("error getting labels: %w", err)
+		}
+
+		for _, label := range labels {
+			byName[label.Name] = label.ID
+		}
+
+		if len(labels) < giteaPageSize {
+			break
+		}
+	}
+
+	return byName, nil
+}
+
+// findMilestone returns the id of the milestone with the given id or title
+func (p *giteaProvider) findMilestone(milestone string) (int64, error) {
+	if id, err := strconv.ParseInt(milestone, 10, 64); err == nil {
+		return id, nil
+	}
+
+	for page := 1; ; page++ {
+		var milestones []giteaMilestone
+		if err := p.do(http.MethodGet, fmt.Sprintf("/milestones?state=all&page=%d&limit=%d", page, giteaPageSize), nil, &milestones); err != nil {
+			return 0, fmt.Errorf("error getting milestones: %w", err)
+		}
+
+		for _, m := range milestones {
+			if m.Title == milestone {
+				return m.ID, nil
+			}
+		}
+
+		if len(milestones) < giteaPageSize {
+			break
+		}
+	}
+
+	return 0, fmt.Errorf("milestone %q not found", milestone)
+}
+
+func (p *giteaProvider) CreateRelease(release Release) error {
+	return p.do(http.MethodPost, "/releases", giteaRelease(release), nil)
+}
+
+func (p *giteaProvider) UpdateRelease(release Release) error {
+	var existing struct {
+		ID int64 `json:"id"`
+	}
+	if err := p.do(http.MethodGet, "/releases/tags/"+url.PathEscape(release.TagName), nil, &existing); err != nil {
+		return fmt.Errorf("error getting release %s: %w", release.TagName, err)
+	}
+
+	return p.do(http.MethodPatch, fmt.Sprintf("/releases/%d", existing.ID), map[string]string{
+		"name": release.Name,
+		"body": release.Body,
+	}, nil)
+}
+
+func (p *giteaProvider) GetRelease(tagName string) (*Release, error) {
+	var release giteaRelease
+	if err := p.do(http.MethodGet, "/releases/tags/"+url.PathEscape(tagName), nil, &release); err != nil {
+		var giteaErr *giteaError
+		if errors.As(err, &giteaErr) && giteaErr.StatusCode == http.StatusNotFound {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("error getting release %s: %w", tagName, err)
+	}
+
+	out := Release(release)
+	return &out, nil
+}
+
+func (p *giteaProvider) do(method, path string, body, out interface{}) error {
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+		reqBody = bytes.NewReader(data)
+	}
+
+	url := fmt.Sprintf("%s/repos/%s/%s%s", p.apiURL, p.owner, p.repo, path)
+
+	req, err := http.NewRequest(method, url, reqBody)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Type", "application/json")
+	if p.accessToken != "" {
+		req.Header.Set("Authorization", "token "+p.accessToken)
+	}
+
+	res, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call %s %s: %w", method, url, err)
+	}
+	defer res.Body.Close()
+
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if res.StatusCode >= http.StatusBadRequest {
+		return &giteaError{
+			Method:     method,
+			URL:        url,
+			StatusCode: res.StatusCode,
+			Header:     res.Header,
+			Body:       strings.TrimSpace(string(data)),
+		}
+	}
+
+	if out == nil || len(data) == 0 {
+		return nil
+	}
+
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	return nil
+}
+
+func giteaTitle(pr PullRequest) string {
+	if pr.Draft {
+		return giteaDraftPrefix + pr.Title
+	}
+
+	return pr.Title
+}
+
+func (pr giteaPullRequest) toPullRequest() PullRequest {
+	return PullRequest{
+		Number:   pr.Number,
+		Title:    strings.TrimPrefix(pr.Title, giteaDraftPrefix),
+		Draft:    strings.HasPrefix(pr.Title, giteaDraftPrefix),
+		Body:     pr.Body,
+		HeadRef:  pr.Head.Ref,
+		HeadRepo: pr.Head.Repo.FullName,
+		BaseRef:  pr.Base.Ref,
+		URL:      pr.HTMLURL,
+	}
+}
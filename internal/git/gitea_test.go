@@ -0,0 +1,240 @@
+package git
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGiteaProvider_ListOpenPullRequests_Paginates(t *testing.T) {
+	requestedPages := []string{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/repos/owner/repo/pulls", r.URL.Path)
+		assert.Equal(t, "token secret", r.Header.Get("Authorization"))
+
+		page := r.URL.Query().Get("page")
+		requestedPages = append(requestedPages, page)
+
+		prs := []giteaPullRequest{}
+		if page == "1" {
+			for i := 0; i < giteaPageSize; i++ {
+				prs = append(prs, giteaPullRequest{Number: i + 1, Title: fmt.Sprintf("PR %d", i+1)})
+			}
+		} else {
+			prs = append(prs, giteaPullRequest{Number: 100, Title: "last", Head: giteaBranch{Ref: "speakeasy-sdk-regen-1"}})
+		}
+
+		_ = json.NewEncoder(w).Encode(prs)
+	}))
+	defer server.Close()
+
+	p := newGiteaProvider(http.DefaultClient, server.URL+"/api/v1/", "secret", "owner", "repo")
+
+	prs, err := p.ListOpenPullRequests()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"1", "2"}, requestedPages)
+	assert.Len(t, prs, giteaPageSize+1)
+	assert.Equal(t, PullRequest{Number: 100, Title: "last", HeadRef: "speakeasy-sdk-regen-1"}, prs[len(prs)-1])
+}
+
+func TestGiteaProvider_CreatePullRequest_Success(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/repos/owner/repo/pulls", r.URL.Path)
+
+		var body map[string]string
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, map[string]string{"title": "title", "body": "body", "head": "branch", "base": "main"}, body)
+
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(giteaPullRequest{
+			Number:  7,
+			Title:   body["title"],
+			Body:    body["body"],
+			HTMLURL: "https://gitea.example.com/owner/repo/pulls/7",
+			Head:    giteaBranch{Ref: body["head"]},
+			Base:    giteaBranch{Ref: body["base"]},
+		})
+	}))
+	defer server.Close()
+
+	p := newGiteaProvider(http.DefaultClient, server.URL, "secret", "owner", "repo")
+
+	pr, err := p.CreatePullRequest(PullRequest{Title: "title", Body: "body", HeadRef: "branch", BaseRef: "main"})
+	require.NoError(t, err)
+	assert.Equal(t, &PullRequest{
+		Number:  7,
+		Title:   "title",
+		Body:    "body",
+		HeadRef: "branch",
+		BaseRef: "main",
+		URL:     "https://gitea.example.com/owner/repo/pulls/7",
+	}, pr)
+}
+
+func TestGiteaProvider_CreateRelease_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/repos/owner/repo/releases", r.URL.Path)
+
+		w.WriteHeader(http.StatusConflict)
+		_, _ = w.Write([]byte(`{"message":"release already exists"}`))
+	}))
+	defer server.Close()
+
+	p := newGiteaProvider(http.DefaultClient, server.URL, "secret", "owner", "repo")
+
+	err := p.CreateRelease(Release{TagName: "v1.0.0"})
+	assert.ErrorContains(t, err, "409")
+	assert.ErrorContains(t, err, "release already exists")
+}
+
+func TestGiteaProvider_CreatePullRequest_Draft(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "WIP: title", body["title"])
+
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(giteaPullRequest{Number: 7, Title: body["title"]})
+	}))
+	defer server.Close()
+
+	p := newGiteaProvider(http.DefaultClient, server.URL, "secret", "owner", "repo")
+
+	pr, err := p.CreatePullRequest(PullRequest{Title: "title", HeadRef: "branch", BaseRef: "main", Draft: true})
+	require.NoError(t, err)
+	assert.Equal(t, "title", pr.Title)
+	assert.True(t, pr.Draft)
+}
+
+func TestGiteaProvider_SetPullRequestMetadata_Success(t *testing.T) {
+	requests := map[string]string{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/repos/owner/repo/labels":
+			_ = json.NewEncoder(w).Encode([]giteaLabel{{ID: 1, Name: "sdk"}, {ID: 2, Name: "automated"}, {ID: 3, Name: "bug"}})
+			return
+		case r.URL.Path == "/repos/owner/repo/milestones":
+			_ = json.NewEncoder(w).Encode([]giteaMilestone{{ID: 4, Title: "v1"}, {ID: 5, Title: "v2"}})
+			return
+		case r.Method == http.MethodGet && r.URL.Path == "/repos/owner/repo/issues/7":
+			_ = json.NewEncoder(w).Encode(giteaIssue{Assignees: []giteaUser{{Login: "rotation"}, {Login: "manual"}}})
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		requests[r.Method+" "+r.URL.Path] = string(body)
+	}))
+	defer server.Close()
+
+	p := newGiteaProvider(http.DefaultClient, server.URL, "secret", "owner", "repo")
+
+	err := p.SetPullRequestMetadata(PullRequest{Number: 7}, PullRequestMetadata{
+		Labels:        []string{"automated", "sdk"},
+		Reviewers:     []string{"octocat"},
+		TeamReviewers: []string{"sdk-owners"},
+		Assignees:     []string{"hubot"},
+		Milestone:     "v2",
+	}, PullRequestMetadata{
+		Labels:        []string{"bug", "deleted"},
+		TeamReviewers: []string{"old-owners"},
+		Assignees:     []string{"rotation"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"DELETE /repos/owner/repo/issues/7/labels/3":           "",
+		"POST /repos/owner/repo/issues/7/labels":               `{"labels":[2,1]}`,
+		"PATCH /repos/owner/repo/issues/7":                     `{"assignees":["manual","hubot"],"milestone":5}`,
+		"DELETE /repos/owner/repo/pulls/7/requested_reviewers": `{"reviewers":null,"team_reviewers":["old-owners"]}`,
+		"POST /repos/owner/repo/pulls/7/requested_reviewers":   `{"reviewers":["octocat"],"team_reviewers":["sdk-owners"]}`,
+	}, requests)
+}
+
+func TestGiteaProvider_SetPullRequestMetadata_RemovesMilestone(t *testing.T) {
+	requests := map[string]string{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		requests[r.Method+" "+r.URL.Path] = string(body)
+	}))
+	defer server.Close()
+
+	p := newGiteaProvider(http.DefaultClient, server.URL, "secret", "owner", "repo")
+
+	err := p.SetPullRequestMetadata(PullRequest{Number: 7}, PullRequestMetadata{}, PullRequestMetadata{Milestone: "v1"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"PATCH /repos/owner/repo/issues/7": `{"milestone":0}`,
+	}, requests)
+}
+
+func TestGiteaProvider_SetPullRequestMetadata_UnknownLabel_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/repos/owner/repo/labels", r.URL.Path)
+		_ = json.NewEncoder(w).Encode([]giteaLabel{{ID: 1, Name: "sdk"}})
+	}))
+	defer server.Close()
+
+	p := newGiteaProvider(http.DefaultClient, server.URL, "secret", "owner", "repo")
+
+	err := p.SetPullRequestMetadata(PullRequest{Number: 7}, PullRequestMetadata{Labels: []string{"missing"}}, PullRequestMetadata{})
+	assert.ErrorContains(t, err, `label "missing" not found`)
+}
+
+func TestGiteaProvider_GetRelease(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodGet, r.Method)
+
+		switch r.URL.Path {
+		case "/repos/owner/repo/releases/tags/v1.0.0":
+			_ = json.NewEncoder(w).Encode(giteaRelease{TagName: "v1.0.0", TargetCommitish: "main", Name: "v1.0.0"})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message":"not found"}`))
+		}
+	}))
+	defer server.Close()
+
+	p := newGiteaProvider(http.DefaultClient, server.URL, "secret", "owner", "repo")
+
+	release, err := p.GetRelease("v1.0.0")
+	require.NoError(t, err)
+	assert.Equal(t, &Release{TagName: "v1.0.0", TargetCommitish: "main", Name: "v1.0.0"}, release)
+
+	release, err = p.GetRelease("v2.0.0")
+	require.NoError(t, err)
+	assert.Nil(t, release)
+}
+
+func TestGiteaProvider_UpdateRelease_Success(t *testing.T) {
+	var body map[string]string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/repos/owner/repo/releases/tags/v1.0.0":
+			_, _ = w.Write([]byte(`{"id":3,"tag_name":"v1.0.0"}`))
+		case r.Method == http.MethodPatch && r.URL.Path == "/repos/owner/repo/releases/3":
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			_, _ = w.Write([]byte(`{"id":3,"tag_name":"v1.0.0"}`))
+		default:
+			t.Errorf("unexpected request to %s %s", r.Method, r.URL.Path)
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	p := newGiteaProvider(http.DefaultClient, server.URL, "secret", "owner", "repo")
+
+	require.NoError(t, p.UpdateRelease(Release{TagName: "v1.0.0", Name: "go - v1.0.0", Body: "body"}))
+	assert.Equal(t, map[string]string{"name": "go - v1.0.0", "body": "body"}, body)
+}
@@ -0,0 +1,386 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-github/v48/github"
+	"github.com/speakeasy-api/sdk-generation-action/internal/environment"
+	"golang.org/x/oauth2"
+)
+
+type githubProvider struct {
+	client *github.Client
+	owner  string
+	repo   string
+}
+
+var _ Provider = (*githubProvider)(nil)
+
+// newGithubClient creates a client for the GitHub API at apiURL, defaulting to api.github.com when empty. The client is anonymous if ts is nil.
+func newGithubClient(httpClient *http.Client, ts oauth2.TokenSource, apiURL string) (*github.Client, error) {
+	if ts != nil {
+		httpClient = oauth2.NewClient(context.WithValue(context.Background(), oauth2.HTTPClient, httpClient), ts)
+	}
+
+	client := github.NewClient(httpClient)
+
+	if apiURL != "" {
+		baseURL, err := url.Parse(strings.TrimSuffix(apiURL, "/") + "/")
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse github api url %s: %w", apiURL, err)
+		}
+		client.BaseURL = baseURL
+
+		// GitHub Enterprise Server serves the REST API at /api/v3/ and uploads release assets to /api/uploads/
+		if strings.HasSuffix(baseURL.Path, "/api/v3/") {
+			uploadURL := *baseURL
+			uploadURL.Path = strings.TrimSuffix(baseURL.Path, "v3/") + "uploads/"
+			client.UploadURL = &uploadURL
+		}
+	}
+
+	return client, nil
+}
+
+func newGithubProvider(httpClient *http.Client, ts oauth2.TokenSource, apiURL, owner, repo string) (*githubProvider, error) {
+	client, err := newGithubClient(httpClient, ts, apiURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &githubProvider{
+		client: client,
+		owner:  owner,
+		repo:   repo,
+	}, nil
+}
+
+func (p *githubProvider) ListOpenPullRequests() ([]PullRequest, error) {
+	opts := &github.PullRequestListOptions{
+		State:       "open",
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+
+	out := []PullRequest{}
+
+	for {
+		prs, resp, err := p.client.PullRequests.List(context.Background(), p.owner, p.repo, opts)
+		if err != nil {
+			return nil, fmt.Errorf("error getting pull requests: %w", err)
+		}
+
+		for _, pr := range prs {
+			out = append(out, fromGithubPullRequest(pr))
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	return out, nil
+}
+
+func (p *githubProvider) CreatePullRequest(pr PullRequest) (*PullRequest, error) {
+	created, _, err := p.client.PullRequests.Create(context.Background(), p.owner, p.repo, &github.NewPullRequest{
+		Title:               github.String(pr.Title),
+		Body:                github.String(pr.Body),
+		Head:                github.String(pr.HeadRef),
+		Base:                github.String(pr.BaseRef),
+		MaintainerCanModify: github.Bool(true),
+		Draft:               github.Bool(pr.Draft),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	out := fromGithubPullRequest(created)
+	return &out, nil
+}
+
+func (p *githubProvider) UpdatePullRequest(pr PullRequest) (*PullRequest, error) {
+	updated, _, err := p.client.PullRequests.Edit(context.Background(), p.owner, p.repo, pr.Number, &github.PullRequest{
+		Title: github.String(pr.Title),
+		Body:  github.String(pr.Body),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	out := fromGithubPullRequest(updated)
+
+	if out.Draft != pr.Draft {
+		if err := p.setDraft(out, pr.Draft); err != nil {
+			return nil, err
+		}
+		out.Draft = pr.Draft
+	}
+
+	return &out, nil
+}
+
+// setDraft converts the PR to a draft or marks it as ready for review, which is only possible through the GraphQL API
+func (p *githubProvider) setDraft(pr PullRequest, draft bool) error {
+	nodeID, err := p.getNodeID(pr)
+	if err != nil {
+		return err
+	}
+
+	mutation := `mutation($id: ID!) {
+  markPullRequestReadyForReview(input: {pullRequestId: $id}) { clientMutationId }
+}`
+	if draft {
+		mutation = `mutation($id: ID!) {
+  convertPullRequestToDraft(input: {pullRequestId: $id}) { clientMutationId }
+}`
+	}
+
+	if err := p.graphql(mutation, map[string]interface{}{"id": nodeID}); err != nil {
+		return fmt.Errorf("failed to set draft state of PR #%d: %w", pr.Number, err)
+	}
+
+	return nil
+}
+
+func (p *githubProvider) ClosePullRequest(pr PullRequest, comment string) error {
+	if _, _, err := p.client.Issues.CreateComment(context.Background(), p.owner, p.repo, pr.Number, &github.IssueComment{
+		Body: github.String(comment),
+	}); err != nil {
+		return err
+	}
+
+	_, _, err := p.client.PullRequests.Edit(context.Background(), p.owner, p.repo, pr.Number, &github.PullRequest{
+		State: github.String("closed"),
+	})
+	return err
+}
+
+func (p *githubProvider) SetPullRequestMetadata(pr PullRequest, metadata, stale PullRequestMetadata) error {
+	ctx := context.Background()
+
+	for _, label := range stale.Labels {
+		if resp, err := p.client.Issues.RemoveLabelForIssue(ctx, p.owner, p.repo, pr.Number, label); err != nil && (resp == nil || resp.StatusCode != http.StatusNotFound) {
+			return fmt.Errorf("failed to remove label %s: %w", label, err)
+		}
+	}
+
+	if len(metadata.Labels) > 0 {
+		if _, _, err := p.client.Issues.AddLabelsToIssue(ctx, p.owner, p.repo, pr.Number, metadata.Labels); err != nil {
+			return fmt.Errorf("failed to add labels: %w", err)
+		}
+	}
+
+	if len(stale.Assignees) > 0 {
+		if _, _, err := p.client.Issues.RemoveAssignees(ctx, p.owner, p.repo, pr.Number, stale.Assignees); err != nil {
+			return fmt.Errorf("failed to remove assignees: %w", err)
+		}
+	}
+
+	if len(metadata.Assignees) > 0 {
+		if _, _, err := p.client.Issues.AddAssignees(ctx, p.owner, p.repo, pr.Number, metadata.Assignees); err != nil {
+			return fmt.Errorf("failed to add assignees: %w", err)
+		}
+	}
+
+	if metadata.Milestone != "" {
+		milestone, err := p.findMilestone(metadata.Milestone)
+		if err != nil {
+			return err
+		}
+
+		if _, _, err := p.client.Issues.Edit(ctx, p.owner, p.repo, pr.Number, &github.IssueRequest{Milestone: github.Int(milestone)}); err != nil {
+			return fmt.Errorf("failed to set milestone: %w", err)
+		}
+	} else if stale.Milestone != "" {
+		if _, _, err := p.client.Issues.RemoveMilestone(ctx, p.owner, p.repo, pr.Number); err != nil {
+			return fmt.Errorf("failed to remove milestone: %w", err)
+		}
+	}
+
+	// Reviewers who have already reviewed the PR are no longer requested, so removing them is a no-op
+	if len(stale.Reviewers) > 0 || len(stale.TeamReviewers) > 0 {
+		if _, err := p.client.PullRequests.RemoveReviewers(ctx, p.owner, p.repo, pr.Number, github.ReviewersRequest{
+			Reviewers:     stale.Reviewers,
+			TeamReviewers: stale.TeamReviewers,
+		}); err != nil {
+			return fmt.Errorf("failed to remove requested reviewers: %w", err)
+		}
+	}
+
+	if len(metadata.Reviewers) > 0 || len(metadata.TeamReviewers) > 0 {
+		if _, _, err := p.client.PullRequests.RequestReviewers(ctx, p.owner, p.repo, pr.Number, github.ReviewersRequest{
+			Reviewers:     metadata.Reviewers,
+			TeamReviewers: metadata.TeamReviewers,
+		}); err != nil {
+			return fmt.Errorf("failed to request reviewers: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// findMilestone returns the number of the milestone with the given number or title
+func (p *githubProvider) findMilestone(milestone string) (int, error) {
+	if number, err := strconv.Atoi(milestone); err == nil {
+		return number, nil
+	}
+
+	opts := &github.MilestoneListOptions{
+		State:       "all",
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+
+	for {
+		milestones, resp, err := p.client.Issues.ListMilestones(context.Background(), p.owner, p.repo, opts)
+		if err != nil {
+			return 0, fmt.Errorf("error getting milestones: %w", err)
+		}
+
+		for _, m := range milestones {
+			if m.GetTitle() == milestone {
+				return m.GetNumber(), nil
+			}
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	return 0, fmt.Errorf("milestone %q not found", milestone)
+}
+
+func (p *githubProvider) EnableAutoMerge(pr PullRequest, method environment.MergeMethod) error {
+	nodeID, err := p.getNodeID(pr)
+	if err != nil {
+		return err
+	}
+
+	return p.graphql(`mutation($id: ID!, $method: PullRequestMergeMethod!) {
+  enablePullRequestAutoMerge(input: {pullRequestId: $id, mergeMethod: $method}) { clientMutationId }
+}`, map[string]interface{}{
+		"id":     nodeID,
+		"method": strings.ToUpper(string(method)),
+	})
+}
+
+func (p *githubProvider) DisableAutoMerge(pr PullRequest) error {
+	nodeID, err := p.getNodeID(pr)
+	if err != nil {
+		return err
+	}
+
+	return p.graphql(`mutation($id: ID!) {
+  disablePullRequestAutoMerge(input: {pullRequestId: $id}) { clientMutationId }
+}`, map[string]interface{}{
+		"id": nodeID,
+	})
+}
+
+// getNodeID returns the GraphQL node id of the PR, which the auto-merge and draft mutations require
+func (p *githubProvider) getNodeID(pr PullRequest) (string, error) {
+	ghPR, _, err := p.client.PullRequests.Get(context.Background(), p.owner, p.repo, pr.Number)
+	if err != nil {
+		return "", fmt.Errorf("error getting pull request #%d: %w", pr.Number, err)
+	}
+
+	return ghPR.GetNodeID(), nil
+}
+
+// graphql runs a GraphQL query as auto-merge and converting PRs to drafts are only available through the GraphQL API
+func (p *githubProvider) graphql(query string, variables map[string]interface{}) error {
+	// GitHub Enterprise Server serves the REST API at /api/v3/ and the GraphQL API at /api/graphql
+	graphqlURL := "graphql"
+	if strings.HasSuffix(p.client.BaseURL.Path, "/api/v3/") {
+		graphqlURL = strings.TrimSuffix(p.client.BaseURL.Path, "v3/") + "graphql"
+	}
+
+	req, err := p.client.NewRequest(http.MethodPost, graphqlURL, map[string]interface{}{
+		"query":     query,
+		"variables": variables,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create graphql request: %w", err)
+	}
+
+	var res struct {
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if _, err := p.client.Do(context.Background(), req, &res); err != nil {
+		return fmt.Errorf("graphql request failed: %w", err)
+	}
+
+	if len(res.Errors) > 0 {
+		messages := []string{}
+		for _, e := range res.Errors {
+			messages = append(messages, e.Message)
+		}
+
+		return fmt.Errorf("graphql request failed: %s", strings.Join(messages, "; "))
+	}
+
+	return nil
+}
+
+func (p *githubProvider) CreateRelease(release Release) error {
+	_, _, err := p.client.Repositories.CreateRelease(context.Background(), p.owner, p.repo, &github.RepositoryRelease{
+		TagName:         github.String(release.TagName),
+		TargetCommitish: github.String(release.TargetCommitish),
+		Name:            github.String(release.Name),
+		Body:            github.String(release.Body),
+	})
+	return err
+}
+
+func (p *githubProvider) UpdateRelease(release Release) error {
+	existing, _, err := p.client.Repositories.GetReleaseByTag(context.Background(), p.owner, p.repo, release.TagName)
+	if err != nil {
+		return fmt.Errorf("error getting release %s: %w", release.TagName, err)
+	}
+
+	_, _, err = p.client.Repositories.EditRelease(context.Background(), p.owner, p.repo, existing.GetID(), &github.RepositoryRelease{
+		Name: github.String(release.Name),
+		Body: github.String(release.Body),
+	})
+	return err
+}
+
+func (p *githubProvider) GetRelease(tagName string) (*Release, error) {
+	release, resp, err := p.client.Repositories.GetReleaseByTag(context.Background(), p.owner, p.repo, tagName)
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("error getting release %s: %w", tagName, err)
+	}
+
+	return &Release{
+		TagName:         release.GetTagName(),
+		TargetCommitish: release.GetTargetCommitish(),
+		Name:            release.GetName(),
+		Body:            release.GetBody(),
+	}, nil
+}
+
+func fromGithubPullRequest(pr *github.PullRequest) PullRequest {
+	return PullRequest{
+		Number:   pr.GetNumber(),
+		Title:    pr.GetTitle(),
+		Body:     pr.GetBody(),
+		HeadRef:  pr.GetHead().GetRef(),
+		HeadRepo: pr.GetHead().GetRepo().GetFullName(),
+		BaseRef:  pr.GetBase().GetRef(),
+		URL:      pr.GetHTMLURL(),
+		Draft:    pr.GetDraft(),
+	}
+}
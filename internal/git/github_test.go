@@ -0,0 +1,94 @@
+package git
+
+import (
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/speakeasy-api/sdk-generation-action/internal/environment"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+)
+
+func TestGithubProvider_EnableAutoMerge_Enterprise(t *testing.T) {
+	var variables map[string]interface{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v3/repos/owner/repo/pulls/3":
+			_, _ = w.Write([]byte(`{"number":3,"node_id":"PR_node"}`))
+		case "/api/graphql":
+			var body struct {
+				Query     string                 `json:"query"`
+				Variables map[string]interface{} `json:"variables"`
+			}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			assert.Contains(t, body.Query, "enablePullRequestAutoMerge")
+			variables = body.Variables
+
+			_, _ = w.Write([]byte(`{"data":{}}`))
+		default:
+			t.Errorf("unexpected request to %s", r.URL.Path)
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	p, err := newGithubProvider(http.DefaultClient, oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "token"}), server.URL+"/api/v3", "owner", "repo")
+	require.NoError(t, err)
+
+	require.NoError(t, p.EnableAutoMerge(PullRequest{Number: 3}, environment.MergeMethodSquash))
+	assert.Equal(t, map[string]interface{}{"id": "PR_node", "method": "SQUASH"}, variables)
+}
+
+func TestGithubProvider_DisableAutoMerge_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/owner/repo/pulls/3":
+			_, _ = w.Write([]byte(`{"number":3,"node_id":"PR_node"}`))
+		case "/graphql":
+			_, _ = w.Write([]byte(`{"errors":[{"message":"Pull request is not in the correct state to disable auto-merge"}]}`))
+		default:
+			t.Errorf("unexpected request to %s", r.URL.Path)
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	p, err := newGithubProvider(http.DefaultClient, oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "token"}), server.URL, "owner", "repo")
+	require.NoError(t, err)
+
+	err = p.DisableAutoMerge(PullRequest{Number: 3})
+	assert.ErrorContains(t, err, "not in the correct state to disable auto-merge")
+}
+
+func TestGithubProvider_CreateRelease_EnterpriseCABundle(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v3/repos/owner/repo/releases", r.URL.Path)
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"tag_name":"v1.0.0"}`))
+	}))
+	defer server.Close()
+
+	ts := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "token"})
+
+	p, err := newGithubProvider(http.DefaultClient, ts, server.URL+"/api/v3", "owner", "repo")
+	require.NoError(t, err)
+	assert.Equal(t, server.URL+"/api/uploads/", p.client.UploadURL.String())
+	assert.ErrorContains(t, p.CreateRelease(Release{TagName: "v1.0.0"}), "certificate")
+
+	httpClient, err := newHTTPClient(string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})))
+	require.NoError(t, err)
+
+	p, err = newGithubProvider(httpClient, ts, server.URL+"/api/v3", "owner", "repo")
+	require.NoError(t, err)
+	assert.NoError(t, p.CreateRelease(Release{TagName: "v1.0.0"}))
+
+	_, err = newHTTPClient("not a certificate")
+	assert.ErrorContains(t, err, "no PEM encoded certificates found")
+}
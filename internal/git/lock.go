@@ -0,0 +1,261 @@
+package git
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/speakeasy-api/sdk-generation-action/internal/logging"
+)
+
+// lockPollInterval is how often a held lock is checked while waiting for it to be released
+const lockPollInterval = 10 * time.Second
+
+// lockRefPrefix namespaces the lock refs so they aren't fetched or shown as branches
+const lockRefPrefix = "refs/speakeasy/locks/"
+
+// lock is the content of the lock ref, stored as the message of a commit with an empty tree
+type lock struct {
+	Owner      string    `json:"owner"`
+	Workflow   string    `json:"workflow"`
+	AcquiredAt time.Time `json:"acquired_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+
+	hash plumbing.Hash
+}
+
+func (l *lock) expired(now time.Time) bool {
+	return now.After(l.ExpiresAt)
+}
+
+// AcquireLock acquires the lock on the base branch for the current workflow run, waiting for up to the configured timeout
+// for another run to release it. A lock held past its expiry is considered stale and broken. Acquiring a lock already held
+// by the current run extends its expiry.
+func (g *Git) AcquireLock() error {
+	if g.repo == nil {
+		return fmt.Errorf("repo not cloned")
+	}
+
+	ref := g.lockRef()
+
+	if g.plan != nil {
+		g.plan.record("acquire lock %s", ref)
+		return nil
+	}
+
+	deadline := time.Now().Add(g.cfg.LockTimeout)
+
+	for {
+		current, err := g.readLock()
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+
+		switch {
+		case current == nil:
+			logging.Info("Acquiring lock %s", ref)
+		case current.Owner == g.cfg.RunID:
+			logging.Info("Lock %s is already held by this run, extending it", ref)
+		case current.expired(now):
+			logging.Info("Breaking stale lock %s held by %s run %s which expired at %s", ref, current.Workflow, current.Owner, current.ExpiresAt.Format(time.RFC3339))
+		default:
+			if !now.Before(deadline) {
+				return fmt.Errorf("timed out waiting for lock %s held by %s run %s until %s", ref, current.Workflow, current.Owner, current.ExpiresAt.Format(time.RFC3339))
+			}
+
+			wait := lockPollInterval
+			if remaining := deadline.Sub(now); remaining < wait {
+				wait = remaining
+			}
+
+			logging.Info("Waiting for lock %s held by %s run %s until %s", ref, current.Workflow, current.Owner, current.ExpiresAt.Format(time.RFC3339))
+			time.Sleep(wait)
+
+			continue
+		}
+
+		acquired, err := g.writeLock(current, now)
+		if err != nil {
+			return err
+		}
+		if acquired {
+			return nil
+		}
+
+		if !time.Now().Before(deadline) {
+			return fmt.Errorf("timed out waiting for lock %s as it kept changing while being acquired", ref)
+		}
+
+		logging.Info("Lock %s changed while acquiring it, retrying", ref)
+	}
+}
+
+// ReleaseLock releases the lock on the base branch if it's held by the current workflow run
+func (g *Git) ReleaseLock() error {
+	if g.repo == nil {
+		return fmt.Errorf("repo not cloned")
+	}
+
+	ref := g.lockRef()
+
+	if g.plan != nil {
+		g.plan.record("release lock %s", ref)
+		return nil
+	}
+
+	current, err := g.readLock()
+	if err != nil {
+		return err
+	}
+
+	if current == nil {
+		logging.Debug("Lock %s is not held", ref)
+		return nil
+	}
+	if current.Owner != g.cfg.RunID {
+		logging.Info("Not releasing lock %s as it's held by %s run %s", ref, current.Workflow, current.Owner)
+		return nil
+	}
+
+	logging.Info("Releasing lock %s", ref)
+
+	if err := g.push(config.RefSpec(fmt.Sprintf(":%s", ref))); err != nil {
+		return fmt.Errorf("error releasing lock: %w", err)
+	}
+
+	return nil
+}
+
+func (g *Git) lockRef() plumbing.ReferenceName {
+	return plumbing.ReferenceName(lockRefPrefix + plumbing.ReferenceName(g.cfg.Ref).Short())
+}
+
+// readLock fetches the lock ref from the remote, returning nil if the lock isn't held
+func (g *Git) readLock() (*lock, error) {
+	ref := g.lockRef()
+
+	refs, err := g.listRemoteRefs()
+	if err != nil {
+		return nil, err
+	}
+
+	var remoteRef *plumbing.Reference
+	for _, r := range refs {
+		if r.Name() == ref {
+			remoteRef = r
+			break
+		}
+	}
+
+	if remoteRef == nil {
+		return nil, nil
+	}
+
+	if err := g.fetch(config.RefSpec(fmt.Sprintf("+%s:%s", ref, ref))); err != nil && err != git.NoErrAlreadyUpToDate {
+		return nil, fmt.Errorf("error fetching lock: %w", err)
+	}
+
+	commit, err := g.repo.CommitObject(remoteRef.Hash())
+	if err != nil {
+		return nil, fmt.Errorf("error getting lock commit: %w", err)
+	}
+
+	l := &lock{hash: commit.Hash}
+	if err := json.Unmarshal([]byte(commit.Message), l); err != nil {
+		// An unreadable lock can't be released by its owner so it's treated as stale
+		logging.Info("Lock %s is invalid, treating it as stale: %v", ref, err)
+		l.Owner = "unknown"
+		l.ExpiresAt = time.Time{}
+	}
+
+	return l, nil
+}
+
+// writeLock pushes a new lock owned by the current run, based on the current lock so the push only succeeds if the lock wasn't updated
+// by another run since it was read. Returns false if the lock changed since it was read, so it needs to be read again.
+func (g *Git) writeLock(current *lock, now time.Time) (bool, error) {
+	ref := g.lockRef()
+
+	message, err := json.Marshal(lock{
+		Owner:      g.cfg.RunID,
+		Workflow:   g.cfg.WorkflowName,
+		AcquiredAt: now.UTC(),
+		ExpiresAt:  now.Add(g.cfg.LockExpiry).UTC(),
+	})
+	if err != nil {
+		return false, fmt.Errorf("error encoding lock: %w", err)
+	}
+
+	treeHash, err := g.storeObject(&object.Tree{})
+	if err != nil {
+		return false, fmt.Errorf("error storing lock tree: %w", err)
+	}
+
+	signature := object.Signature{
+		Name:  g.cfg.CommitterName,
+		Email: g.cfg.CommitterEmail,
+		When:  now,
+	}
+
+	commit := &object.Commit{
+		Author:    signature,
+		Committer: signature,
+		Message:   string(message),
+		TreeHash:  treeHash,
+	}
+	if current != nil {
+		commit.ParentHashes = []plumbing.Hash{current.hash}
+	}
+
+	commitHash, err := g.storeObject(commit)
+	if err != nil {
+		return false, fmt.Errorf("error storing lock commit: %w", err)
+	}
+
+	if err := g.repo.Storer.SetReference(plumbing.NewHashReference(ref, commitHash)); err != nil {
+		return false, fmt.Errorf("error setting lock ref: %w", err)
+	}
+
+	if err := g.push(config.RefSpec(fmt.Sprintf("%s:%s", ref, ref))); err != nil {
+		// The push is rejected if another run created or updated the lock after it was read
+		if strings.Contains(err.Error(), "non-fast-forward") {
+			return false, nil
+		}
+
+		// The remote may reject the update itself, in which case the lock is read again to check whether it changed, including being released
+		latest, readErr := g.readLock()
+		if readErr != nil {
+			return false, fmt.Errorf("error pushing lock: %w", err)
+		}
+		if latest != nil && latest.hash == commitHash {
+			return true, nil
+		}
+		if (latest == nil) != (current == nil) || (latest != nil && latest.hash != current.hash) {
+			return false, nil
+		}
+
+		return false, fmt.Errorf("error pushing lock: %w", err)
+	}
+
+	return true, nil
+}
+
+type encodable interface {
+	Encode(plumbing.EncodedObject) error
+}
+
+func (g *Git) storeObject(obj encodable) (plumbing.Hash, error) {
+	encoded := g.repo.Storer.NewEncodedObject()
+	if err := obj.Encode(encoded); err != nil {
+		return plumbing.ZeroHash, err
+	}
+
+	return g.repo.Storer.SetEncodedObject(encoded)
+}
@@ -0,0 +1,172 @@
+package git
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-billy/v5/util"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"github.com/speakeasy-api/sdk-generation-action/internal/environment"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+)
+
+// newLockTestRemote creates a bare repo with a main branch to act as the remote shared by the runs contending for the lock
+func newLockTestRemote(t *testing.T) string {
+	t.Helper()
+
+	remote := t.TempDir()
+	_, err := git.PlainInit(remote, true)
+	require.NoError(t, err)
+
+	seed, err := git.Init(memory.NewStorage(), memfs.New())
+	require.NoError(t, err)
+
+	w, err := seed.Worktree()
+	require.NoError(t, err)
+	require.NoError(t, util.WriteFile(w.Filesystem, "gen.yaml", []byte("version: 1.0.0"), 0o644))
+	_, err = w.Add(".")
+	require.NoError(t, err)
+	_, err = w.Commit("initial", &git.CommitOptions{Author: &object.Signature{Name: "test", When: time.Now()}})
+	require.NoError(t, err)
+
+	_, err = seed.CreateRemote(&config.RemoteConfig{Name: "origin", URLs: []string{remote}})
+	require.NoError(t, err)
+	require.NoError(t, seed.Push(&git.PushOptions{RefSpecs: []config.RefSpec{"refs/heads/master:refs/heads/main"}}))
+
+	return remote
+}
+
+// newLockTestGit returns a Git for the workflow run with the given ID, cloned from the remote
+func newLockTestGit(t *testing.T, remote, runID string) *Git {
+	t.Helper()
+
+	repo, err := git.Clone(memory.NewStorage(), memfs.New(), &git.CloneOptions{URL: remote, ReferenceName: plumbing.NewBranchReferenceName("main")})
+	require.NoError(t, err)
+
+	cfg := &environment.Config{
+		Ref:            "refs/heads/main",
+		RunID:          runID,
+		WorkflowName:   "test",
+		CommitterName:  "speakeasybot",
+		CommitterEmail: "bot@speakeasyapi.dev",
+		LockTimeout:    time.Millisecond,
+		LockExpiry:     time.Hour,
+	}
+
+	return &Git{cfg: cfg, repo: repo, tokenSource: oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "token"})}
+}
+
+func TestAcquireLock(t *testing.T) {
+	remote := newLockTestRemote(t)
+	g := newLockTestGit(t, remote, "1")
+
+	require.NoError(t, g.AcquireLock())
+
+	held, err := g.readLock()
+	require.NoError(t, err)
+	require.NotNil(t, held)
+	assert.Equal(t, "1", held.Owner)
+	assert.Equal(t, "test", held.Workflow)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), held.ExpiresAt, time.Minute)
+
+	// Acquiring the lock again extends it
+	g.cfg.LockExpiry = 2 * time.Hour
+	require.NoError(t, g.AcquireLock())
+
+	extended, err := g.readLock()
+	require.NoError(t, err)
+	require.NotNil(t, extended)
+	assert.Equal(t, "1", extended.Owner)
+	assert.True(t, extended.ExpiresAt.After(held.ExpiresAt))
+}
+
+func TestAcquireLock_Contention(t *testing.T) {
+	remote := newLockTestRemote(t)
+	holder := newLockTestGit(t, remote, "1")
+	g := newLockTestGit(t, remote, "2")
+
+	require.NoError(t, holder.AcquireLock())
+
+	err := g.AcquireLock()
+	assert.ErrorContains(t, err, "timed out waiting for lock refs/speakeasy/locks/main held by test run 1")
+
+	require.NoError(t, holder.ReleaseLock())
+	require.NoError(t, g.AcquireLock())
+
+	held, err := g.readLock()
+	require.NoError(t, err)
+	require.NotNil(t, held)
+	assert.Equal(t, "2", held.Owner)
+}
+
+func TestWriteLock_ChangedSinceRead(t *testing.T) {
+	remote := newLockTestRemote(t)
+	other := newLockTestGit(t, remote, "1")
+	g := newLockTestGit(t, remote, "2")
+
+	// The lock was free when read, but acquired by the other run before it was written
+	current, err := g.readLock()
+	require.NoError(t, err)
+	require.Nil(t, current)
+
+	require.NoError(t, other.AcquireLock())
+
+	acquired, err := g.writeLock(current, time.Now())
+	require.NoError(t, err)
+	assert.False(t, acquired, "the lock should be read again rather than overwritten")
+
+	// The other run released the lock, so reading it again finds it free
+	require.NoError(t, other.ReleaseLock())
+
+	acquired, err = g.writeLock(current, time.Now())
+	require.NoError(t, err)
+	assert.True(t, acquired)
+}
+
+func TestAcquireLock_BreaksStaleLock(t *testing.T) {
+	remote := newLockTestRemote(t)
+	stale := newLockTestGit(t, remote, "1")
+	g := newLockTestGit(t, remote, "2")
+
+	stale.cfg.LockExpiry = -time.Minute
+	require.NoError(t, stale.AcquireLock())
+
+	require.NoError(t, g.AcquireLock())
+
+	held, err := g.readLock()
+	require.NoError(t, err)
+	require.NotNil(t, held)
+	assert.Equal(t, "2", held.Owner)
+}
+
+func TestReleaseLock(t *testing.T) {
+	remote := newLockTestRemote(t)
+	holder := newLockTestGit(t, remote, "1")
+	other := newLockTestGit(t, remote, "2")
+
+	// Releasing a lock that isn't held does nothing
+	require.NoError(t, holder.ReleaseLock())
+
+	require.NoError(t, holder.AcquireLock())
+
+	// Only the run holding the lock can release it
+	require.NoError(t, other.ReleaseLock())
+
+	held, err := holder.readLock()
+	require.NoError(t, err)
+	require.NotNil(t, held)
+	assert.Equal(t, "1", held.Owner)
+
+	require.NoError(t, holder.ReleaseLock())
+
+	held, err = holder.readLock()
+	require.NoError(t, err)
+	assert.Nil(t, held)
+}
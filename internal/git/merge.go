@@ -0,0 +1,300 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/speakeasy-api/sdk-generation-action/internal/environment"
+	"github.com/speakeasy-api/sdk-generation-action/internal/logging"
+	"github.com/speakeasy-api/sdk-generation-action/internal/templates"
+	"github.com/speakeasy-api/sdk-generation-action/pkg/releases"
+)
+
+// MergeConflictError is returned by MergeBranch when the branch can't be merged as the base branch changed after the branch was created
+type MergeConflictError struct {
+	Branch   string
+	Base     string
+	Strategy environment.MergeStrategy
+	// Files are the files with conflicting changes, empty if the base branch moved on without conflicting with the branch
+	Files  []string
+	Reason string
+}
+
+func (e *MergeConflictError) Error() string {
+	return fmt.Sprintf("failed to merge %s into %s using the %s strategy: %s", e.Branch, e.Base, e.Strategy, e.Reason)
+}
+
+// Report describes the conflict in markdown for the PR opened in place of merging the branch
+func (e *MergeConflictError) Report() string {
+	var report strings.Builder
+
+	report.WriteString("## Merge conflict\n\n")
+	fmt.Fprintf(&report, "This PR was opened as `%s` couldn't be merged into `%s` using the `%s` strategy: %s.", e.Branch, e.Base, e.Strategy, e.Reason)
+
+	if len(e.Files) > 0 {
+		report.WriteString("\n\nConflicting files:\n")
+		for _, file := range e.Files {
+			fmt.Fprintf(&report, "\n- `%s`", file)
+		}
+	}
+
+	return report.String()
+}
+
+// IsMergeConflict checks whether err is caused by a merge conflict, returning the conflict if so
+func IsMergeConflict(err error) (*MergeConflictError, bool) {
+	var conflict *MergeConflictError
+	if errors.As(err, &conflict) {
+		return conflict, true
+	}
+
+	return nil, false
+}
+
+// MergeBranch merges the branch into the latest commit of the base branch using the configured merge strategy and pushes the base branch.
+// A *MergeConflictError is returned if the base branch has changed in a way that prevents the merge, previous is the release history before the release.
+func (g *Git) MergeBranch(branchName string, releaseInfo releases.ReleasesInfo, previous releases.Releases) (string, error) {
+	if g.repo == nil {
+		return "", fmt.Errorf("repo not cloned")
+	}
+
+	base := plumbing.ReferenceName(g.cfg.Ref)
+
+	if err := g.CheckoutLatestBase(); err != nil {
+		return "", err
+	}
+
+	logging.Info("Merging branch %s into %s using the %s strategy", branchName, base.Short(), g.cfg.MergeStrategy)
+
+	var err error
+	switch g.cfg.MergeStrategy {
+	case environment.MergeStrategyFastForward:
+		err = g.mergeFastForward(branchName)
+	case environment.MergeStrategySquash:
+		err = g.mergeSquash(branchName, releaseInfo, previous)
+	case environment.MergeStrategyRebase:
+		err = g.mergeRebase(branchName)
+	default:
+		err = g.mergeCommit(branchName)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	headRef, err := g.repo.Head()
+	if err != nil {
+		return "", fmt.Errorf("error getting head ref: %w", err)
+	}
+
+	if g.plan != nil {
+		g.plan.record("push merge of %s into %s at %s", branchName, g.cfg.Ref, headRef.Hash().String())
+		return headRef.Hash().String(), nil
+	}
+
+	if err := g.push(config.RefSpec(fmt.Sprintf("%s:%s", base, base))); err != nil {
+		// The base branch was pushed to after it was fetched
+		if strings.Contains(err.Error(), "non-fast-forward") {
+			return "", g.mergeConflict(branchName, nil, fmt.Sprintf("%s was updated while merging", base.Short()))
+		}
+
+		return "", fmt.Errorf("error pushing changes: %w", err)
+	}
+
+	return headRef.Hash().String(), nil
+}
+
+// CheckoutLatestBase fetches the latest commit of the base branch and checks it out, discarding any local changes
+func (g *Git) CheckoutLatestBase() error {
+	if g.repo == nil {
+		return fmt.Errorf("repo not cloned")
+	}
+
+	base := plumbing.ReferenceName(g.cfg.Ref)
+	remoteRef := plumbing.NewRemoteReferenceName("origin", base.Short())
+
+	if err := g.fetch(config.RefSpec(fmt.Sprintf("+%s:%s", base, remoteRef))); err != nil && err != git.NoErrAlreadyUpToDate {
+		return fmt.Errorf("error fetching %s: %w", base.Short(), err)
+	}
+
+	latest, err := g.repo.Reference(remoteRef, true)
+	if err != nil {
+		return fmt.Errorf("error getting %s: %w", remoteRef, err)
+	}
+
+	w, err := g.repo.Worktree()
+	if err != nil {
+		return fmt.Errorf("error getting worktree: %w", err)
+	}
+
+	if err := w.Checkout(&git.CheckoutOptions{
+		Branch: base,
+		Force:  true,
+	}); err != nil {
+		return fmt.Errorf("error checking out branch: %w", err)
+	}
+
+	if err := w.Reset(&git.ResetOptions{
+		Commit: latest.Hash(),
+		Mode:   git.HardReset,
+	}); err != nil {
+		return fmt.Errorf("error resetting %s: %w", base.Short(), err)
+	}
+
+	return nil
+}
+
+func (g *Git) mergeFastForward(branchName string) error {
+	head, err := g.repo.Head()
+	if err != nil {
+		return fmt.Errorf("error getting head ref: %w", err)
+	}
+	baseCommit, err := g.repo.CommitObject(head.Hash())
+	if err != nil {
+		return fmt.Errorf("error getting commit: %w", err)
+	}
+
+	branchRef, err := g.repo.Reference(plumbing.NewBranchReferenceName(branchName), true)
+	if err != nil {
+		return fmt.Errorf("error getting branch %s: %w", branchName, err)
+	}
+	branchCommit, err := g.repo.CommitObject(branchRef.Hash())
+	if err != nil {
+		return fmt.Errorf("error getting commit: %w", err)
+	}
+
+	ff, err := baseCommit.IsAncestor(branchCommit)
+	if err != nil {
+		return fmt.Errorf("error comparing %s to %s: %w", branchName, head.Name().Short(), err)
+	}
+	if !ff {
+		return g.mergeConflict(branchName, nil, fmt.Sprintf("%s has commits that %s is not based on so it can't be fast-forwarded", head.Name().Short(), branchName))
+	}
+
+	if _, err := runGitCommand("merge", "--ff-only", branchName); err != nil {
+		return fmt.Errorf("error merging branch: %w", err)
+	}
+
+	return nil
+}
+
+func (g *Git) mergeCommit(branchName string) error {
+	output, err := g.runGitCommandAsCommitter("merge", "--no-edit", branchName)
+	if err != nil {
+		return g.abort(branchName, err, "merge", "--abort")
+	}
+
+	logging.Debug("Merge output: %s", output)
+
+	if g.signer == nil {
+		return nil
+	}
+
+	head, err := g.repo.Head()
+	if err != nil {
+		return fmt.Errorf("error getting head ref: %w", err)
+	}
+	commit, err := g.repo.CommitObject(head.Hash())
+	if err != nil {
+		return fmt.Errorf("error getting commit: %w", err)
+	}
+
+	// Only sign the merge commit, a fast-forward merge doesn't create one
+	if len(commit.ParentHashes) > 1 {
+		if _, err := g.signCommit(commit.Hash); err != nil {
+			return fmt.Errorf("error signing commit: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (g *Git) mergeSquash(branchName string, releaseInfo releases.ReleasesInfo, previous releases.Releases) error {
+	if _, err := runGitCommand("merge", "--squash", branchName); err != nil {
+		return g.abort(branchName, err, "reset", "--hard", "HEAD")
+	}
+
+	message, err := g.render(templates.CommitMessage, templates.NewData(g.cfg, releaseInfo, previous))
+	if err != nil {
+		return err
+	}
+
+	w, err := g.repo.Worktree()
+	if err != nil {
+		return fmt.Errorf("error getting worktree: %w", err)
+	}
+
+	_, err = g.commit(w, message, true)
+	return err
+}
+
+func (g *Git) mergeRebase(branchName string) error {
+	head, err := g.repo.Head()
+	if err != nil {
+		return fmt.Errorf("error getting head ref: %w", err)
+	}
+	base := head.Name().Short()
+
+	if _, err := g.runGitCommandAsCommitter("rebase", base, branchName); err != nil {
+		return g.abort(branchName, err, "rebase", "--abort")
+	}
+
+	if g.signer != nil {
+		rebased, err := g.repo.Head()
+		if err != nil {
+			return fmt.Errorf("error getting head ref: %w", err)
+		}
+
+		if _, err := g.signCommits(head.Hash(), rebased.Hash()); err != nil {
+			return fmt.Errorf("error signing commits: %w", err)
+		}
+	}
+
+	if _, err := runGitCommand("checkout", base); err != nil {
+		return fmt.Errorf("error checking out branch: %w", err)
+	}
+
+	if _, err := runGitCommand("merge", "--ff-only", branchName); err != nil {
+		return fmt.Errorf("error merging branch: %w", err)
+	}
+
+	return nil
+}
+
+// abort aborts a failed merge using the given git command, returning a *MergeConflictError if the merge failed due to conflicts
+func (g *Git) abort(branchName string, mergeErr error, args ...string) error {
+	output, err := runGitCommand("diff", "--name-only", "--diff-filter=U")
+	if err != nil {
+		return fmt.Errorf("error getting conflicting files: %w", err)
+	}
+
+	if _, err := runGitCommand(args...); err != nil {
+		return fmt.Errorf("error aborting merge: %w", err)
+	}
+
+	files := []string{}
+	for _, line := range strings.Split(output, "\n") {
+		if file := strings.TrimSpace(line); file != "" {
+			files = append(files, file)
+		}
+	}
+
+	if len(files) == 0 {
+		return fmt.Errorf("error merging branch: %w", mergeErr)
+	}
+
+	return g.mergeConflict(branchName, files, "both branches changed the same files")
+}
+
+func (g *Git) mergeConflict(branchName string, files []string, reason string) *MergeConflictError {
+	return &MergeConflictError{
+		Branch:   branchName,
+		Base:     plumbing.ReferenceName(g.cfg.Ref).Short(),
+		Strategy: g.cfg.MergeStrategy,
+		Files:    files,
+		Reason:   reason,
+	}
+}
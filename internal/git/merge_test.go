@@ -0,0 +1,36 @@
+package git
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/speakeasy-api/sdk-generation-action/internal/environment"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergeConflictError_Report(t *testing.T) {
+	conflict := &MergeConflictError{
+		Branch:   "speakeasy-sdk-regen-1",
+		Base:     "main",
+		Strategy: environment.MergeStrategyRebase,
+		Files:    []string{"RELEASES.md", "go/sdk.go"},
+		Reason:   "both branches changed the same files",
+	}
+
+	assert.Equal(t, "failed to merge speakeasy-sdk-regen-1 into main using the rebase strategy: both branches changed the same files", conflict.Error())
+	assert.Equal(t, "## Merge conflict\n\n"+
+		"This PR was opened as `speakeasy-sdk-regen-1` couldn't be merged into `main` using the `rebase` strategy: both branches changed the same files.\n\n"+
+		"Conflicting files:\n\n"+
+		"- `RELEASES.md`\n"+
+		"- `go/sdk.go`", conflict.Report())
+
+	conflict.Files = nil
+	assert.NotContains(t, conflict.Report(), "Conflicting files")
+
+	wrapped, ok := IsMergeConflict(fmt.Errorf("finalize failed: %w", conflict))
+	assert.True(t, ok)
+	assert.Equal(t, conflict, wrapped)
+
+	_, ok = IsMergeConflict(fmt.Errorf("error pushing changes"))
+	assert.False(t, ok)
+}
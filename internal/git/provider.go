@@ -0,0 +1,157 @@
+package git
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/speakeasy-api/sdk-generation-action/internal/environment"
+	"golang.org/x/oauth2"
+)
+
+type PullRequest struct {
+	Number  int
+	Title   string
+	Body    string
+	HeadRef string
+	// HeadRepo is the full name (owner/repo) of the repo the head branch is in, which differs from the repo for PRs from forks
+	HeadRepo string
+	BaseRef  string
+	URL      string
+	Draft    bool
+}
+
+// PullRequestMetadata is applied to the regeneration PR when it is created and re-synced every time it is updated
+type PullRequestMetadata struct {
+	Labels        []string `json:"labels,omitempty"`
+	Reviewers     []string `json:"reviewers,omitempty"`
+	TeamReviewers []string `json:"team_reviewers,omitempty"`
+	Assignees     []string `json:"assignees,omitempty"`
+	Milestone     string   `json:"milestone,omitempty"`
+}
+
+func (m PullRequestMetadata) IsEmpty() bool {
+	return len(m.Labels) == 0 && len(m.Reviewers) == 0 && len(m.TeamReviewers) == 0 && len(m.Assignees) == 0 && m.Milestone == ""
+}
+
+// Stale returns the labels, reviewers and assignees of previous that are no longer in m, and the milestone of previous if m has none
+func (m PullRequestMetadata) Stale(previous PullRequestMetadata) PullRequestMetadata {
+	stale := PullRequestMetadata{
+		Labels:        missing(previous.Labels, m.Labels),
+		Reviewers:     missing(previous.Reviewers, m.Reviewers),
+		TeamReviewers: missing(previous.TeamReviewers, m.TeamReviewers),
+		Assignees:     missing(previous.Assignees, m.Assignees),
+	}
+	if m.Milestone == "" {
+		stale.Milestone = previous.Milestone
+	}
+
+	return stale
+}
+
+// missing returns the values not in other
+func missing(values, other []string) []string {
+	out := []string{}
+	for _, value := range values {
+		found := false
+		for _, o := range other {
+			if strings.EqualFold(value, o) {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			out = append(out, value)
+		}
+	}
+
+	return out
+}
+
+func (m PullRequestMetadata) String() string {
+	parts := []string{}
+
+	add := func(name string, values []string) {
+		if len(values) > 0 {
+			parts = append(parts, fmt.Sprintf("%s=%s", name, strings.Join(values, ",")))
+		}
+	}
+	add("labels", m.Labels)
+	add("reviewers", m.Reviewers)
+	add("team_reviewers", m.TeamReviewers)
+	add("assignees", m.Assignees)
+	if m.Milestone != "" {
+		parts = append(parts, "milestone="+m.Milestone)
+	}
+
+	return strings.Join(parts, " ")
+}
+
+type Release struct {
+	TagName         string
+	TargetCommitish string
+	Name            string
+	Body            string
+}
+
+// Provider abstracts the forge (GitHub, Gitea, etc.) hosting the SDK repo for the operations that can't be done via git itself.
+type Provider interface {
+	ListOpenPullRequests() ([]PullRequest, error)
+	CreatePullRequest(pr PullRequest) (*PullRequest, error)
+	// UpdatePullRequest updates the title and body of the PR, converting it to or from a draft to match pr.Draft
+	UpdatePullRequest(pr PullRequest) (*PullRequest, error)
+	ClosePullRequest(pr PullRequest, comment string) error
+	// SetPullRequestMetadata adds the labels, reviewers and assignees of metadata to the PR and sets its milestone, removing the
+	// labels, reviewers, assignees and milestone of stale. Any others are left in place.
+	SetPullRequestMetadata(pr PullRequest, metadata, stale PullRequestMetadata) error
+	// EnableAutoMerge merges the PR using the given method once its required checks and reviews pass
+	EnableAutoMerge(pr PullRequest, method environment.MergeMethod) error
+	DisableAutoMerge(pr PullRequest) error
+	CreateRelease(release Release) error
+	// UpdateRelease updates the name and body of the existing release for the tag
+	UpdateRelease(release Release) error
+	// GetRelease returns the release for the tag, or nil if there isn't one
+	GetRelease(tagName string) (*Release, error)
+}
+
+func newProvider(cfg *environment.Config, httpClient *http.Client, ts oauth2.TokenSource) (Provider, error) {
+	owner := cfg.RepositoryOwner
+	repo := cfg.GetRepoName()
+
+	switch cfg.Provider {
+	case environment.ProviderGitHub:
+		return newGithubProvider(httpClient, ts, cfg.APIURL, owner, repo)
+	case environment.ProviderGitea:
+		apiURL := cfg.ProviderAPIURL
+		if apiURL == "" {
+			apiURL = cfg.ServerURL + "/api/v1"
+		}
+
+		return newGiteaProvider(httpClient, apiURL, cfg.AccessToken, owner, repo), nil
+	default:
+		return nil, fmt.Errorf("unsupported provider: %s", cfg.Provider)
+	}
+}
+
+// newHTTPClient creates the client for API requests, trusting the CA certificates in caBundle in addition to the system's
+func newHTTPClient(caBundle string) (*http.Client, error) {
+	if caBundle == "" {
+		return http.DefaultClient, nil
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM([]byte(caBundle)) {
+		return nil, fmt.Errorf("failed to parse ca bundle: no PEM encoded certificates found")
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+
+	return &http.Client{Transport: transport}, nil
+}
@@ -1,45 +1,176 @@
 package git
 
 import (
-	"context"
+	"encoding/json"
 	"fmt"
-	"os"
+	"sort"
 
-	"github.com/google/go-github/v48/github"
+	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/speakeasy-api/sdk-generation-action/internal/environment"
+	"github.com/speakeasy-api/sdk-generation-action/internal/logging"
+	"github.com/speakeasy-api/sdk-generation-action/internal/templates"
 	"github.com/speakeasy-api/sdk-generation-action/pkg/releases"
 )
 
-func (g *Git) CreateRelease(releaseInfo releases.ReleasesInfo) error {
+// ReleaseStatus is the outcome of creating the release for a language
+type ReleaseStatus string
+
+const (
+	ReleaseCreated ReleaseStatus = "created"
+	ReleaseUpdated ReleaseStatus = "updated"
+	ReleaseSkipped ReleaseStatus = "skipped"
+	ReleaseFailed  ReleaseStatus = "failed"
+)
+
+type ReleaseResult struct {
+	Language string        `json:"language"`
+	TagName  string        `json:"tag"`
+	Status   ReleaseStatus `json:"status"`
+	Error    string        `json:"error,omitempty"`
+}
+
+type ReleaseResults []ReleaseResult
+
+// Failed returns the results of the releases that couldn't be created
+func (r ReleaseResults) Failed() ReleaseResults {
+	failed := ReleaseResults{}
+	for _, result := range r {
+		if result.Status == ReleaseFailed {
+			failed = append(failed, result)
+		}
+	}
+
+	return failed
+}
+
+func (r ReleaseResults) JSON() (string, error) {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal release results: %w", err)
+	}
+
+	return string(data), nil
+}
+
+// CreateRelease creates a release for each language in the release, previous is the release history before it. Languages whose release
+// already exists are skipped or updated according to the existing release policy, and a failure to release one language doesn't stop
+// the others from being released. The returned error is only set if no release could be attempted.
+func (g *Git) CreateRelease(releaseInfo releases.ReleasesInfo, previous releases.Releases) (ReleaseResults, error) {
 	if g.repo == nil {
-		return fmt.Errorf("repo not cloned")
+		return nil, fmt.Errorf("repo not cloned")
 	}
 
 	fmt.Println("Creating release")
 
 	headRef, err := g.repo.Head()
 	if err != nil {
-		return fmt.Errorf("failed to get head ref: %w", err)
+		return nil, fmt.Errorf("failed to get head ref: %w", err)
 	}
 
 	commitHash := headRef.Hash().String()
 
-	for lang, info := range releaseInfo.Languages {
-		tag := "v" + info.Version
-		if info.Path != "." {
-			tag = fmt.Sprintf("%s/%s", info.Path, tag)
+	tags, err := g.listRemoteTags()
+	if err != nil {
+		logging.Info("Failed to list existing tags: %s", err.Error())
+	}
+
+	data := templates.NewData(g.cfg, releaseInfo, previous)
+
+	langs := make([]string, 0, len(releaseInfo.Languages))
+	for lang := range releaseInfo.Languages {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+
+	results := ReleaseResults{}
+
+	for _, lang := range langs {
+		result := ReleaseResult{
+			Language: lang,
+			TagName:  releaseInfo.Languages[lang].Tag(),
 		}
 
-		_, _, err = g.client.Repositories.CreateRelease(context.Background(), os.Getenv("GITHUB_REPOSITORY_OWNER"), getRepo(), &github.RepositoryRelease{
-			TagName:         github.String(tag),
-			TargetCommitish: github.String(commitHash),
-			Name:            github.String(fmt.Sprintf("%s - %s - %s", lang, tag, environment.GetInvokeTime().Format("2006-01-02 15:04:05"))),
-			Body:            github.String(fmt.Sprintf(`# Generated by Speakeasy CLI%s`, releaseInfo)),
-		})
+		result.Status, err = g.createLanguageRelease(lang, result.TagName, commitHash, data.ForLanguage(lang), tags[result.TagName])
 		if err != nil {
-			return fmt.Errorf("failed to create release: %w", err)
+			result.Error = err.Error()
+			logging.Info("Failed to release %s as %s: %s", lang, result.TagName, result.Error)
+		} else {
+			logging.Info("%s release %s: %s", lang, result.TagName, result.Status)
+		}
+
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+func (g *Git) createLanguageRelease(lang, tagName, commitHash string, data templates.Data, tagExists bool) (ReleaseStatus, error) {
+	name, err := g.render(templates.ReleaseName, data)
+	if err != nil {
+		return ReleaseFailed, err
+	}
+
+	body, err := g.render(templates.ReleaseBody, data)
+	if err != nil {
+		return ReleaseFailed, err
+	}
+
+	release := Release{
+		TagName:         tagName,
+		TargetCommitish: commitHash,
+		Name:            name,
+		Body:            body,
+	}
+
+	existing, err := g.provider.GetRelease(tagName)
+	if err != nil {
+		return ReleaseFailed, err
+	}
+
+	if existing == nil {
+		// The tag is left where it is, such as when it was pushed by a publishing step before the release step was re-run
+		if tagExists {
+			logging.Info("Tag %s already exists, creating the %s release for it", tagName, lang)
+		}
+
+		if err := g.provider.CreateRelease(release); err != nil {
+			return ReleaseFailed, fmt.Errorf("failed to create release: %w", err)
+		}
+
+		return ReleaseCreated, nil
+	}
+
+	switch g.cfg.ExistingReleasePolicy {
+	case environment.ExistingReleaseUpdate:
+		if existing.Name == release.Name && existing.Body == release.Body {
+			return ReleaseSkipped, nil
+		}
+
+		if err := g.provider.UpdateRelease(release); err != nil {
+			return ReleaseFailed, fmt.Errorf("failed to update release: %w", err)
+		}
+
+		return ReleaseUpdated, nil
+	case environment.ExistingReleaseFail:
+		return ReleaseFailed, fmt.Errorf("release %s already exists", tagName)
+	default:
+		return ReleaseSkipped, nil
+	}
+}
+
+// listRemoteTags lists the names of the tags on the remote
+func (g *Git) listRemoteTags() (map[string]bool, error) {
+	refs, err := g.listRemoteRefs()
+	if err != nil {
+		return nil, err
+	}
+
+	tags := map[string]bool{}
+	for _, ref := range refs {
+		if ref.Type() == plumbing.HashReference && ref.Name().IsTag() {
+			tags[ref.Name().Short()] = true
 		}
 	}
 
-	return nil
+	return tags, nil
 }
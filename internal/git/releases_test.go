@@ -0,0 +1,160 @@
+package git
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-billy/v5/util"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"github.com/speakeasy-api/sdk-generation-action/internal/environment"
+	"github.com/speakeasy-api/sdk-generation-action/internal/templates"
+	"github.com/speakeasy-api/sdk-generation-action/pkg/releases"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// releasesProvider serves the existing releases, failing to create the releases for the tags in failTags
+type releasesProvider struct {
+	recordingProvider
+	releases map[string]Release
+	failTags map[string]bool
+}
+
+func (p *releasesProvider) GetRelease(tagName string) (*Release, error) {
+	if release, ok := p.releases[tagName]; ok {
+		return &release, nil
+	}
+
+	return nil, nil
+}
+
+func (p *releasesProvider) CreateRelease(release Release) error {
+	if p.failTags[release.TagName] {
+		return errors.New("validation failed")
+	}
+
+	p.calls = append(p.calls, "create release "+release.TagName)
+	p.releases[release.TagName] = release
+
+	return nil
+}
+
+func (p *releasesProvider) UpdateRelease(release Release) error {
+	p.calls = append(p.calls, "update release "+release.TagName)
+	p.releases[release.TagName] = release
+
+	return nil
+}
+
+func TestCreateRelease_ExistingReleasePolicy(t *testing.T) {
+	releaseInfo := releases.ReleasesInfo{
+		DocVersion:       "1.1.0",
+		SpeakeasyVersion: "1.20.0",
+		Languages: map[string]releases.LanguageReleaseInfo{
+			"go":         {Path: "go", Version: "1.1.0"},
+			"python":     {Path: "python", Version: "2.0.0"},
+			"typescript": {Path: "typescript", Version: "3.0.0"},
+		},
+	}
+
+	tests := []struct {
+		name        string
+		policy      environment.ExistingReleasePolicy
+		existing    Release
+		wantGo      ReleaseResult
+		wantGoCalls []string
+	}{
+		{
+			name:     "skip",
+			policy:   environment.ExistingReleaseSkip,
+			existing: Release{TagName: "go/v1.1.0", Name: "edited"},
+			wantGo:   ReleaseResult{Language: "go", TagName: "go/v1.1.0", Status: ReleaseSkipped},
+		},
+		{
+			name:        "update",
+			policy:      environment.ExistingReleaseUpdate,
+			existing:    Release{TagName: "go/v1.1.0", Name: "edited"},
+			wantGo:      ReleaseResult{Language: "go", TagName: "go/v1.1.0", Status: ReleaseUpdated},
+			wantGoCalls: []string{"update release go/v1.1.0"},
+		},
+		{
+			name:     "fail",
+			policy:   environment.ExistingReleaseFail,
+			existing: Release{TagName: "go/v1.1.0", Name: "edited"},
+			wantGo:   ReleaseResult{Language: "go", TagName: "go/v1.1.0", Status: ReleaseFailed, Error: "release go/v1.1.0 already exists"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &releasesProvider{
+				releases: map[string]Release{tt.existing.TagName: tt.existing},
+				failTags: map[string]bool{"python/v2.0.0": true},
+			}
+			g := newReleaseTestGit(t, tt.policy, p)
+
+			results, err := g.CreateRelease(releaseInfo, nil)
+			require.NoError(t, err)
+
+			assert.Equal(t, ReleaseResults{
+				tt.wantGo,
+				{Language: "python", TagName: "python/v2.0.0", Status: ReleaseFailed, Error: "failed to create release: validation failed"},
+				{Language: "typescript", TagName: "typescript/v3.0.0", Status: ReleaseCreated},
+			}, results)
+			assert.Equal(t, append(tt.wantGoCalls, "create release typescript/v3.0.0"), p.calls)
+		})
+	}
+}
+
+func TestCreateRelease_Rerun(t *testing.T) {
+	releaseInfo := releases.ReleasesInfo{
+		DocVersion:       "1.1.0",
+		SpeakeasyVersion: "1.20.0",
+		Languages: map[string]releases.LanguageReleaseInfo{
+			"go":     {Path: "go", Version: "1.1.0"},
+			"python": {Path: "python", Version: "2.0.0"},
+		},
+	}
+
+	p := &releasesProvider{releases: map[string]Release{}}
+	g := newReleaseTestGit(t, environment.ExistingReleaseUpdate, p)
+
+	results, err := g.CreateRelease(releaseInfo, nil)
+	require.NoError(t, err)
+	assert.Empty(t, results.Failed())
+	assert.Equal(t, []string{"create release go/v1.1.0", "create release python/v2.0.0"}, p.calls)
+
+	// The releases are unchanged so aren't updated
+	p.calls = nil
+
+	results, err = g.CreateRelease(releaseInfo, nil)
+	require.NoError(t, err)
+	assert.Equal(t, ReleaseResults{
+		{Language: "go", TagName: "go/v1.1.0", Status: ReleaseSkipped},
+		{Language: "python", TagName: "python/v2.0.0", Status: ReleaseSkipped},
+	}, results)
+	assert.Empty(t, p.calls)
+}
+
+func newReleaseTestGit(t *testing.T, policy environment.ExistingReleasePolicy, provider Provider) *Git {
+	t.Helper()
+
+	repo, err := git.Init(memory.NewStorage(), memfs.New())
+	require.NoError(t, err)
+
+	w, err := repo.Worktree()
+	require.NoError(t, err)
+	require.NoError(t, util.WriteFile(w.Filesystem, "RELEASES.md", []byte("# Releases"), 0o644))
+	_, err = w.Add(".")
+	require.NoError(t, err)
+	_, err = w.Commit("initial", &git.CommitOptions{Author: &object.Signature{Name: "test", When: time.Now()}})
+	require.NoError(t, err)
+
+	tmpls, err := templates.Load(t.TempDir(), false)
+	require.NoError(t, err)
+
+	return &Git{cfg: &environment.Config{ExistingReleasePolicy: policy}, repo: repo, provider: provider, templates: tmpls}
+}
@@ -0,0 +1,301 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	gitHttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/google/go-github/v48/github"
+	"github.com/speakeasy-api/sdk-generation-action/internal/environment"
+	"github.com/speakeasy-api/sdk-generation-action/internal/logging"
+)
+
+// retryPolicy is how calls to the forge's API and the git remote are retried on transient failures, using exponential backoff with jitter
+type retryPolicy struct {
+	attempts  int
+	baseDelay time.Duration
+	// maxDelay caps the backoff, a retry is not attempted if the server asks to wait any longer
+	maxDelay time.Duration
+}
+
+var defaultRetryPolicy = retryPolicy{
+	attempts:  4,
+	baseDelay: time.Second,
+	maxDelay:  2 * time.Minute,
+}
+
+// transientError describes a failure that may succeed if retried
+type transientError struct {
+	// after is the delay requested by the server before retrying, if any
+	after time.Duration
+	// rejected is set when the server refused the request without processing it, such as when rate limited
+	rejected bool
+}
+
+// do calls fn until it succeeds, fails with an error that isn't transient or runs out of attempts. The attempt number is passed to fn
+// so it can check whether a failed attempt took effect before repeating a call that isn't idempotent.
+func (p retryPolicy) do(op string, fn func(attempt int) error) error {
+	return p.run(op, false, fn)
+}
+
+// doRejected is like do but only retries requests the server rejected without processing, for calls that can't be safely repeated
+func (p retryPolicy) doRejected(op string, fn func(attempt int) error) error {
+	return p.run(op, true, fn)
+}
+
+func (p retryPolicy) run(op string, onlyRejected bool, fn func(attempt int) error) error {
+	for attempt := 1; ; attempt++ {
+		err := fn(attempt)
+		if err == nil {
+			return nil
+		}
+
+		transient, ok := classifyError(err)
+		if !ok || (onlyRejected && !transient.rejected) || attempt >= p.attempts {
+			return err
+		}
+
+		delay := p.backoff(attempt)
+		if transient.after > p.maxDelay {
+			return err
+		}
+		if transient.after > delay {
+			delay = transient.after
+		}
+
+		logging.Info("Failed to %s, retrying in %s (attempt %d of %d): %v", op, delay.Round(time.Millisecond), attempt+1, p.attempts, err)
+		time.Sleep(delay)
+	}
+}
+
+// backoff returns the delay before the given retry, doubling with every retry with up to half of it randomized
+func (p retryPolicy) backoff(retry int) time.Duration {
+	delay := p.baseDelay << (retry - 1)
+	if delay <= 0 || delay > p.maxDelay {
+		delay = p.maxDelay
+	}
+
+	half := delay / 2
+	if half <= 0 {
+		return delay
+	}
+
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// classifyError checks whether the error returned by the GitHub or Gitea API or the git remote is transient
+func classifyError(err error) (transientError, bool) {
+	var rateLimit *github.RateLimitError
+	if errors.As(err, &rateLimit) {
+		return transientError{after: time.Until(rateLimit.Rate.Reset.Time), rejected: true}, true
+	}
+
+	var abuseRateLimit *github.AbuseRateLimitError
+	if errors.As(err, &abuseRateLimit) {
+		return transientError{after: abuseRateLimit.GetRetryAfter(), rejected: true}, true
+	}
+
+	var githubErr *github.ErrorResponse
+	if errors.As(err, &githubErr) && githubErr.Response != nil {
+		return classifyResponse(githubErr.Response.StatusCode, githubErr.Response.Header)
+	}
+
+	var giteaErr *giteaError
+	if errors.As(err, &giteaErr) {
+		return classifyResponse(giteaErr.StatusCode, giteaErr.Header)
+	}
+
+	// go-git doesn't support unwrapping its errors
+	var unexpected *plumbing.UnexpectedError
+	if errors.As(err, &unexpected) {
+		var gitErr *gitHttp.Err
+		if errors.As(unexpected.Err, &gitErr) {
+			return classifyResponse(gitErr.Response.StatusCode, gitErr.Response.Header)
+		}
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return transientError{}, true
+	}
+
+	if errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return transientError{}, true
+	}
+
+	return transientError{}, false
+}
+
+func classifyResponse(statusCode int, header http.Header) (transientError, bool) {
+	transient := transientError{
+		after:    parseRetryAfter(header),
+		rejected: statusCode == http.StatusTooManyRequests,
+	}
+
+	switch statusCode {
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return transient, true
+	case http.StatusForbidden:
+		// Secondary rate limits respond with a 403 and a Retry-After header
+		if header.Get("Retry-After") != "" || header.Get("X-RateLimit-Remaining") == "0" {
+			transient.rejected = true
+			if transient.after == 0 {
+				transient.after = untilRateLimitReset(header)
+			}
+
+			return transient, true
+		}
+	}
+
+	return transientError{}, false
+}
+
+// parseRetryAfter parses the Retry-After header in either of its seconds or HTTP date forms
+func parseRetryAfter(header http.Header) time.Duration {
+	value := header.Get("Retry-After")
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		return time.Until(date)
+	}
+
+	return 0
+}
+
+func untilRateLimitReset(header http.Header) time.Duration {
+	reset, err := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64)
+	if err != nil {
+		return 0
+	}
+
+	return time.Until(time.Unix(reset, 0))
+}
+
+// retryingProvider wraps a provider retrying its operations on transient failures
+type retryingProvider struct {
+	Provider
+	policy retryPolicy
+}
+
+var _ Provider = (*retryingProvider)(nil)
+
+func (p *retryingProvider) ListOpenPullRequests() ([]PullRequest, error) {
+	var prs []PullRequest
+	err := p.policy.do("list pull requests", func(int) error {
+		var err error
+		prs, err = p.Provider.ListOpenPullRequests()
+		return err
+	})
+
+	return prs, err
+}
+
+func (p *retryingProvider) CreatePullRequest(pr PullRequest) (*PullRequest, error) {
+	var created *PullRequest
+	err := p.policy.do(fmt.Sprintf("create PR from %s", pr.HeadRef), func(attempt int) error {
+		// The PR may have been created by a previous attempt that failed before responding
+		if attempt > 1 {
+			prs, err := p.Provider.ListOpenPullRequests()
+			if err != nil {
+				return err
+			}
+
+			for _, existing := range prs {
+				if existing.HeadRef == pr.HeadRef && existing.BaseRef == pr.BaseRef {
+					created = &existing
+					return nil
+				}
+			}
+		}
+
+		var err error
+		created, err = p.Provider.CreatePullRequest(pr)
+		return err
+	})
+
+	return created, err
+}
+
+func (p *retryingProvider) UpdatePullRequest(pr PullRequest) (*PullRequest, error) {
+	var updated *PullRequest
+	err := p.policy.do(fmt.Sprintf("update PR #%d", pr.Number), func(int) error {
+		var err error
+		updated, err = p.Provider.UpdatePullRequest(pr)
+		return err
+	})
+
+	return updated, err
+}
+
+func (p *retryingProvider) ClosePullRequest(pr PullRequest, comment string) error {
+	// Retrying once the comment is posted would post it again
+	return p.policy.doRejected(fmt.Sprintf("close PR #%d", pr.Number), func(int) error {
+		return p.Provider.ClosePullRequest(pr, comment)
+	})
+}
+
+func (p *retryingProvider) SetPullRequestMetadata(pr PullRequest, metadata, stale PullRequestMetadata) error {
+	return p.policy.do(fmt.Sprintf("set metadata on PR #%d", pr.Number), func(int) error {
+		return p.Provider.SetPullRequestMetadata(pr, metadata, stale)
+	})
+}
+
+func (p *retryingProvider) EnableAutoMerge(pr PullRequest, method environment.MergeMethod) error {
+	return p.policy.do(fmt.Sprintf("enable auto-merge of PR #%d", pr.Number), func(int) error {
+		return p.Provider.EnableAutoMerge(pr, method)
+	})
+}
+
+func (p *retryingProvider) DisableAutoMerge(pr PullRequest) error {
+	return p.policy.do(fmt.Sprintf("disable auto-merge of PR #%d", pr.Number), func(int) error {
+		return p.Provider.DisableAutoMerge(pr)
+	})
+}
+
+func (p *retryingProvider) CreateRelease(release Release) error {
+	return p.policy.do(fmt.Sprintf("create release %s", release.TagName), func(attempt int) error {
+		// The release may have been created by a previous attempt that failed before responding
+		if attempt > 1 {
+			existing, err := p.Provider.GetRelease(release.TagName)
+			if err != nil {
+				return err
+			}
+			if existing != nil {
+				return nil
+			}
+		}
+
+		return p.Provider.CreateRelease(release)
+	})
+}
+
+func (p *retryingProvider) UpdateRelease(release Release) error {
+	return p.policy.do(fmt.Sprintf("update release %s", release.TagName), func(int) error {
+		return p.Provider.UpdateRelease(release)
+	})
+}
+
+func (p *retryingProvider) GetRelease(tagName string) (*Release, error) {
+	var release *Release
+	err := p.policy.do(fmt.Sprintf("get release %s", tagName), func(int) error {
+		var err error
+		release, err = p.Provider.GetRelease(tagName)
+		return err
+	})
+
+	return release, err
+}
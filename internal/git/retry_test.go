@@ -0,0 +1,231 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	gitHttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/google/go-github/v48/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+)
+
+var testRetryPolicy = retryPolicy{attempts: 3, baseDelay: time.Millisecond, maxDelay: 10 * time.Millisecond}
+
+func statusError(statusCode int, header http.Header) error {
+	if header == nil {
+		header = http.Header{}
+	}
+
+	return &giteaError{Method: http.MethodPost, URL: "https://gitea.example.com", StatusCode: statusCode, Header: header}
+}
+
+func TestRetryPolicy_Do(t *testing.T) {
+	tests := []struct {
+		name         string
+		errs         []error
+		onlyRejected bool
+		wantAttempts int
+		wantErr      bool
+	}{
+		{name: "success", errs: []error{nil}, wantAttempts: 1},
+		{name: "transient then success", errs: []error{statusError(502, nil), statusError(503, nil), nil}, wantAttempts: 3},
+		{name: "out of attempts", errs: []error{statusError(502, nil), statusError(502, nil), statusError(502, nil)}, wantAttempts: 3, wantErr: true},
+		{name: "not transient", errs: []error{statusError(422, nil)}, wantAttempts: 1, wantErr: true},
+		{name: "only rejected", errs: []error{statusError(429, nil), statusError(502, nil)}, onlyRejected: true, wantAttempts: 2, wantErr: true},
+		{name: "retry after too long", errs: []error{statusError(429, http.Header{"Retry-After": []string{"3600"}})}, wantAttempts: 1, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := 0
+			fn := func(attempt int) error {
+				attempts++
+				assert.Equal(t, attempts, attempt)
+				return tt.errs[attempt-1]
+			}
+
+			var err error
+			if tt.onlyRejected {
+				err = testRetryPolicy.doRejected("test", fn)
+			} else {
+				err = testRetryPolicy.do("test", fn)
+			}
+
+			assert.Equal(t, tt.wantAttempts, attempts)
+			if tt.wantErr {
+				assert.Equal(t, tt.errs[attempts-1], err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	p := retryPolicy{attempts: 10, baseDelay: time.Second, maxDelay: 10 * time.Second}
+
+	for retry, max := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 5: 10 * time.Second, 64: 10 * time.Second} {
+		delay := p.backoff(retry)
+		assert.GreaterOrEqual(t, delay, max/2, "retry %d", retry)
+		assert.LessOrEqual(t, delay, max, "retry %d", retry)
+	}
+}
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestClassifyError(t *testing.T) {
+	response := func(statusCode int, header http.Header) *http.Response {
+		if header == nil {
+			header = http.Header{}
+		}
+
+		return &http.Response{StatusCode: statusCode, Header: header, Request: &http.Request{Method: http.MethodGet, URL: &url.URL{}}}
+	}
+	retryAfter := 30 * time.Second
+
+	tests := []struct {
+		name         string
+		err          error
+		wantOK       bool
+		wantRejected bool
+		wantAfter    time.Duration
+	}{
+		{name: "github bad gateway", err: &github.ErrorResponse{Response: response(502, nil)}, wantOK: true},
+		{name: "github not found", err: fmt.Errorf("wrapped: %w", &github.ErrorResponse{Response: response(404, nil)})},
+		{name: "github validation failed", err: &github.ErrorResponse{Response: response(422, nil)}},
+		{name: "github secondary rate limit", err: &github.ErrorResponse{Response: response(403, http.Header{"Retry-After": []string{"30"}})}, wantOK: true, wantRejected: true, wantAfter: retryAfter},
+		{name: "github forbidden", err: &github.ErrorResponse{Response: response(403, nil)}},
+		{name: "github abuse rate limit", err: &github.AbuseRateLimitError{Response: response(403, nil), RetryAfter: &retryAfter}, wantOK: true, wantRejected: true, wantAfter: retryAfter},
+		{name: "gitea too many requests", err: statusError(429, http.Header{"Retry-After": []string{"30"}}), wantOK: true, wantRejected: true, wantAfter: retryAfter},
+		{name: "git service unavailable", err: plumbing.NewUnexpectedError(&gitHttp.Err{Response: response(503, nil)}), wantOK: true},
+		{name: "timeout", err: &url.Error{Op: "Get", URL: "https://api.github.com", Err: timeoutError{}}, wantOK: true},
+		{name: "other", err: errors.New("non-fast-forward update")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transient, ok := classifyError(tt.err)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.wantRejected, transient.rejected)
+			assert.Equal(t, tt.wantAfter, transient.after)
+		})
+	}
+
+	transient, ok := classifyError(&github.RateLimitError{Rate: github.Rate{Reset: github.Timestamp{Time: time.Now().Add(time.Minute)}}, Response: response(403, nil)})
+	assert.True(t, ok)
+	assert.True(t, transient.rejected)
+	assert.InDelta(t, time.Minute, transient.after, float64(time.Second))
+}
+
+// flakyProvider fails the first call of each operation after it took effect, as if the response was lost
+type flakyProvider struct {
+	recordingProvider
+	err      error
+	releases map[string]Release
+}
+
+func (p *flakyProvider) CreatePullRequest(pr PullRequest) (*PullRequest, error) {
+	created, _ := p.recordingProvider.CreatePullRequest(pr)
+	p.prs = append(p.prs, *created)
+
+	return nil, p.fail()
+}
+
+func (p *flakyProvider) ClosePullRequest(pr PullRequest, comment string) error {
+	_ = p.recordingProvider.ClosePullRequest(pr, comment)
+
+	return p.fail()
+}
+
+func (p *flakyProvider) CreateRelease(release Release) error {
+	_ = p.recordingProvider.CreateRelease(release)
+	p.releases[release.TagName] = release
+
+	return p.fail()
+}
+
+func (p *flakyProvider) GetRelease(tagName string) (*Release, error) {
+	_, _ = p.recordingProvider.GetRelease(tagName)
+
+	if release, ok := p.releases[tagName]; ok {
+		return &release, nil
+	}
+
+	return nil, nil
+}
+
+func (p *flakyProvider) fail() error {
+	err := p.err
+	p.err = nil
+
+	return err
+}
+
+func TestRetryingProvider_IdempotentCreation(t *testing.T) {
+	inner := &flakyProvider{err: statusError(http.StatusBadGateway, nil), releases: map[string]Release{}}
+	p := &retryingProvider{Provider: inner, policy: testRetryPolicy}
+
+	require.NoError(t, p.CreateRelease(Release{TagName: "v1.0.0"}))
+	assert.Equal(t, []string{"release", "get release v1.0.0"}, inner.calls, "the release should not be created again")
+
+	inner.calls = nil
+	inner.err = statusError(http.StatusBadGateway, nil)
+
+	pr, err := p.CreatePullRequest(PullRequest{Title: "regen", HeadRef: "speakeasy-sdk-regen-1", BaseRef: "main"})
+	require.NoError(t, err)
+	assert.Equal(t, "regen", pr.Title)
+	assert.Equal(t, []string{"create", "list"}, inner.calls, "the PR should not be created again")
+}
+
+func TestRetryingProvider_ClosePullRequest(t *testing.T) {
+	inner := &flakyProvider{err: statusError(http.StatusBadGateway, nil)}
+	p := &retryingProvider{Provider: inner, policy: testRetryPolicy}
+
+	assert.Error(t, p.ClosePullRequest(PullRequest{Number: 1}, "superseded"))
+	assert.Len(t, inner.calls, 1, "the comment should not be posted again")
+
+	inner.calls = nil
+	inner.err = statusError(http.StatusTooManyRequests, nil)
+
+	require.NoError(t, p.ClosePullRequest(PullRequest{Number: 1}, "superseded"))
+	assert.Len(t, inner.calls, 2)
+}
+
+func TestRetryingProvider_GithubSecondaryRateLimit(t *testing.T) {
+	requests := 0
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/repos/owner/repo/pulls", r.URL.Path)
+
+		requests++
+		if requests == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"message":"You have exceeded a secondary rate limit"}`))
+			return
+		}
+
+		_, _ = w.Write([]byte(`[{"number":1,"head":{"ref":"speakeasy-sdk-regen-1"}}]`))
+	}))
+	defer server.Close()
+
+	github, err := newGithubProvider(http.DefaultClient, oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "token"}), server.URL, "owner", "repo")
+	require.NoError(t, err)
+
+	p := &retryingProvider{Provider: github, policy: testRetryPolicy}
+
+	prs, err := p.ListOpenPullRequests()
+	require.NoError(t, err)
+	assert.Equal(t, 2, requests)
+	assert.Equal(t, []PullRequest{{Number: 1, HeadRef: "speakeasy-sdk-regen-1"}}, prs)
+}
@@ -2,16 +2,21 @@ package logging
 
 import (
 	"fmt"
-
-	"github.com/speakeasy-api/sdk-generation-action/internal/environment"
 )
 
+var debug bool
+
+// SetDebug enables debug logging, called once the action's configuration is loaded
+func SetDebug(enabled bool) {
+	debug = enabled
+}
+
 func Info(msg string, args ...interface{}) {
 	fmt.Println("INFO: ", fmt.Sprintf(msg, args...))
 }
 
 func Debug(msg string, args ...interface{}) {
-	if environment.IsDebugMode() {
+	if debug {
 		fmt.Println("::debug::", fmt.Sprintf(msg, args...))
 	}
 }
@@ -0,0 +1,449 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pb33f/libopenapi"
+	"github.com/pb33f/libopenapi/datamodel/high/base"
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+	"golang.org/x/exp/slices"
+)
+
+type ChangeLevel string
+
+const (
+	ChangeLevelNone     ChangeLevel = "none"
+	ChangeLevelCosmetic ChangeLevel = "cosmetic"
+	ChangeLevelAdditive ChangeLevel = "additive"
+	ChangeLevelBreaking ChangeLevel = "breaking"
+)
+
+var changeLevelOrder = map[ChangeLevel]int{
+	ChangeLevelNone:     0,
+	ChangeLevelCosmetic: 1,
+	ChangeLevelAdditive: 2,
+	ChangeLevelBreaking: 3,
+}
+
+// maxSchemaDepth limits how deep inline schemas are compared to protect against recursive schemas
+const maxSchemaDepth = 10
+
+type Change struct {
+	Level       ChangeLevel `json:"level"`
+	Description string      `json:"description"`
+}
+
+type ChangeReport struct {
+	Level   ChangeLevel `json:"level"`
+	Changes []Change    `json:"changes,omitempty"`
+}
+
+// schemaContext determines which changes to a schema are breaking, as clients send requests but only read responses
+type schemaContext int
+
+const (
+	schemaContextRequest schemaContext = iota
+	schemaContextResponse
+	schemaContextComponent
+)
+
+// Compare structurally compares two revisions of an OpenAPI document and classifies the changes between them.
+func Compare(previous, current []byte) (*ChangeReport, error) {
+	if bytes.Equal(previous, current) {
+		return &ChangeReport{Level: ChangeLevelNone}, nil
+	}
+
+	previousModel, err := buildModel(previous)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load previous openapi doc: %w", err)
+	}
+
+	currentModel, err := buildModel(current)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load current openapi doc: %w", err)
+	}
+
+	r := &ChangeReport{}
+
+	r.compareOperations(getOperations(previousModel), getOperations(currentModel))
+	r.compareComponentSchemas(getComponentSchemas(previousModel), getComponentSchemas(currentModel))
+
+	sort.SliceStable(r.Changes, func(i, j int) bool {
+		if r.Changes[i].Level != r.Changes[j].Level {
+			return changeLevelOrder[r.Changes[i].Level] > changeLevelOrder[r.Changes[j].Level]
+		}
+		return r.Changes[i].Description < r.Changes[j].Description
+	})
+
+	// The documents differ but not in any way that affects the generated SDKs
+	r.Level = ChangeLevelCosmetic
+	for _, c := range r.Changes {
+		if changeLevelOrder[c.Level] > changeLevelOrder[r.Level] {
+			r.Level = c.Level
+		}
+	}
+
+	return r, nil
+}
+
+// Merge combines the changes from multiple reports, the resulting level is the most severe of the reports.
+func Merge(reports ...*ChangeReport) *ChangeReport {
+	var merged *ChangeReport
+	seen := map[Change]bool{}
+
+	for _, r := range reports {
+		if r == nil {
+			continue
+		}
+
+		if merged == nil {
+			merged = &ChangeReport{Level: r.Level}
+		} else if changeLevelOrder[r.Level] > changeLevelOrder[merged.Level] {
+			merged.Level = r.Level
+		}
+
+		for _, c := range r.Changes {
+			if !seen[c] {
+				seen[c] = true
+				merged.Changes = append(merged.Changes, c)
+			}
+		}
+	}
+
+	return merged
+}
+
+func ParseReport(data string) (*ChangeReport, error) {
+	if strings.TrimSpace(data) == "" {
+		return nil, nil
+	}
+
+	var r ChangeReport
+	if err := json.Unmarshal([]byte(data), &r); err != nil {
+		return nil, fmt.Errorf("failed to parse openapi change report: %w", err)
+	}
+
+	return &r, nil
+}
+
+func (r ChangeReport) JSON() (string, error) {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal openapi change report: %w", err)
+	}
+
+	return string(data), nil
+}
+
+func (r ChangeReport) Markdown() string {
+	lines := []string{
+		"## OpenAPI Changes",
+		"",
+		fmt.Sprintf("Classification: **%s**", r.Level),
+	}
+
+	if len(r.Changes) > 0 {
+		lines = append(lines, "")
+	}
+
+	for _, c := range r.Changes {
+		lines = append(lines, fmt.Sprintf("- %s: %s", c.Level, c.Description))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func (r *ChangeReport) add(level ChangeLevel, format string, args ...interface{}) {
+	r.Changes = append(r.Changes, Change{
+		Level:       level,
+		Description: fmt.Sprintf(format, args...),
+	})
+}
+
+func (r *ChangeReport) compareOperations(previous, current map[string]*v3.Operation) {
+	for _, key := range sortedKeys(previous) {
+		currentOp, ok := current[key]
+		if !ok {
+			r.add(ChangeLevelBreaking, "removed operation `%s`", key)
+			continue
+		}
+
+		r.compareOperation(key, previous[key], currentOp)
+	}
+
+	for _, key := range sortedKeys(current) {
+		if _, ok := previous[key]; !ok {
+			r.add(ChangeLevelAdditive, "added operation `%s`", key)
+		}
+	}
+}
+
+func (r *ChangeReport) compareOperation(key string, previous, current *v3.Operation) {
+	previousParams := getParameters(previous)
+	currentParams := getParameters(current)
+
+	for _, name := range sortedKeys(previousParams) {
+		currentParam, ok := currentParams[name]
+		if !ok {
+			r.add(ChangeLevelBreaking, "removed parameter `%s` from `%s`", name, key)
+			continue
+		}
+
+		if !previousParams[name].Required && currentParam.Required {
+			r.add(ChangeLevelBreaking, "parameter `%s` of `%s` is now required", name, key)
+		}
+
+		r.compareSchemaProxies(fmt.Sprintf("parameter `%s` of `%s`", name, key), previousParams[name].Schema, currentParam.Schema, schemaContextRequest, 0)
+	}
+
+	for _, name := range sortedKeys(currentParams) {
+		if _, ok := previousParams[name]; ok {
+			continue
+		}
+
+		if currentParams[name].Required {
+			r.add(ChangeLevelBreaking, "added required parameter `%s` to `%s`", name, key)
+		} else {
+			r.add(ChangeLevelAdditive, "added optional parameter `%s` to `%s`", name, key)
+		}
+	}
+
+	if previous.RequestBody != nil && current.RequestBody != nil {
+		if !previous.RequestBody.Required && current.RequestBody.Required {
+			r.add(ChangeLevelBreaking, "request body of `%s` is now required", key)
+		}
+
+		r.compareContent(fmt.Sprintf("request body of `%s`", key), previous.RequestBody.Content, current.RequestBody.Content, schemaContextRequest)
+	} else if previous.RequestBody != nil {
+		r.add(ChangeLevelBreaking, "removed request body from `%s`", key)
+	} else if current.RequestBody != nil {
+		if current.RequestBody.Required {
+			r.add(ChangeLevelBreaking, "added required request body to `%s`", key)
+		} else {
+			r.add(ChangeLevelAdditive, "added optional request body to `%s`", key)
+		}
+	}
+
+	previousResponses := getResponses(previous)
+	currentResponses := getResponses(current)
+
+	for _, code := range sortedKeys(previousResponses) {
+		currentResponse, ok := currentResponses[code]
+		if !ok {
+			r.add(ChangeLevelBreaking, "removed `%s` response from `%s`", code, key)
+			continue
+		}
+
+		r.compareContent(fmt.Sprintf("`%s` response of `%s`", code, key), previousResponses[code].Content, currentResponse.Content, schemaContextResponse)
+	}
+
+	for _, code := range sortedKeys(currentResponses) {
+		if _, ok := previousResponses[code]; !ok {
+			r.add(ChangeLevelAdditive, "added `%s` response to `%s`", code, key)
+		}
+	}
+}
+
+func (r *ChangeReport) compareContent(location string, previous, current map[string]*v3.MediaType, ctx schemaContext) {
+	for _, mediaType := range sortedKeys(previous) {
+		currentMediaType, ok := current[mediaType]
+		if !ok {
+			r.add(ChangeLevelBreaking, "removed `%s` content from %s", mediaType, location)
+			continue
+		}
+
+		r.compareSchemaProxies(location, previous[mediaType].Schema, currentMediaType.Schema, ctx, 0)
+	}
+
+	for _, mediaType := range sortedKeys(current) {
+		if _, ok := previous[mediaType]; !ok {
+			r.add(ChangeLevelAdditive, "added `%s` content to %s", mediaType, location)
+		}
+	}
+}
+
+func (r *ChangeReport) compareComponentSchemas(previous, current map[string]*base.SchemaProxy) {
+	for _, name := range sortedKeys(previous) {
+		currentSchema, ok := current[name]
+		if !ok {
+			r.add(ChangeLevelBreaking, "removed schema `%s`", name)
+			continue
+		}
+
+		r.compareSchemas(fmt.Sprintf("schema `%s`", name), previous[name].Schema(), currentSchema.Schema(), schemaContextComponent, 0)
+	}
+
+	for _, name := range sortedKeys(current) {
+		if _, ok := previous[name]; !ok {
+			r.add(ChangeLevelAdditive, "added schema `%s`", name)
+		}
+	}
+}
+
+func (r *ChangeReport) compareSchemaProxies(location string, previous, current *base.SchemaProxy, ctx schemaContext, depth int) {
+	if previous == nil || current == nil {
+		return
+	}
+
+	// Referenced component schemas are compared separately so only a change of reference is relevant here
+	previousRef := getSchemaReference(previous)
+	currentRef := getSchemaReference(current)
+	if previousRef != "" && currentRef != "" {
+		if previousRef != currentRef {
+			r.add(ChangeLevelBreaking, "%s changed from `%s` to `%s`", location, previousRef, currentRef)
+		}
+		return
+	}
+
+	r.compareSchemas(location, previous.Schema(), current.Schema(), ctx, depth)
+}
+
+func (r *ChangeReport) compareSchemas(location string, previous, current *base.Schema, ctx schemaContext, depth int) {
+	if previous == nil || current == nil || depth > maxSchemaDepth {
+		return
+	}
+
+	previousType := strings.Join(sortedTypes(previous.Type), "|")
+	currentType := strings.Join(sortedTypes(current.Type), "|")
+	if previousType != currentType {
+		r.add(ChangeLevelBreaking, "type of %s changed from `%s` to `%s`", location, previousType, currentType)
+		return
+	}
+
+	for _, name := range sortedKeys(previous.Properties) {
+		fieldLocation := fmt.Sprintf("field `%s` of %s", name, location)
+
+		currentProperty, ok := current.Properties[name]
+		if !ok {
+			r.add(ChangeLevelBreaking, "removed %s", fieldLocation)
+			continue
+		}
+
+		if ctx != schemaContextResponse && !slices.Contains(previous.Required, name) && slices.Contains(current.Required, name) {
+			r.add(ChangeLevelBreaking, "%s is now required", fieldLocation)
+		}
+
+		r.compareSchemaProxies(fieldLocation, previous.Properties[name], currentProperty, ctx, depth+1)
+	}
+
+	for _, name := range sortedKeys(current.Properties) {
+		if _, ok := previous.Properties[name]; ok {
+			continue
+		}
+
+		if ctx != schemaContextResponse && slices.Contains(current.Required, name) {
+			r.add(ChangeLevelBreaking, "added required field `%s` to %s", name, location)
+		} else {
+			r.add(ChangeLevelAdditive, "added field `%s` to %s", name, location)
+		}
+	}
+
+	if previous.Items != nil && current.Items != nil && previous.Items.IsA() && current.Items.IsA() {
+		r.compareSchemaProxies(fmt.Sprintf("items of %s", location), previous.Items.A, current.Items.A, ctx, depth+1)
+	}
+}
+
+func buildModel(data []byte) (*v3.Document, error) {
+	doc, err := libopenapi.NewDocument(data)
+	if err != nil {
+		return nil, err
+	}
+
+	model, errs := doc.BuildV3Model()
+	if len(errs) > 0 {
+		return nil, errs[0]
+	}
+	if model == nil {
+		return nil, fmt.Errorf("model is nil")
+	}
+
+	return &model.Model, nil
+}
+
+func getOperations(doc *v3.Document) map[string]*v3.Operation {
+	ops := map[string]*v3.Operation{}
+
+	if doc.Paths == nil {
+		return ops
+	}
+
+	for path, item := range doc.Paths.PathItems {
+		for method, op := range item.GetOperations() {
+			// Path level parameters apply to every operation on the path
+			if len(item.Parameters) > 0 {
+				opCopy := *op
+				opCopy.Parameters = append(append([]*v3.Parameter{}, item.Parameters...), op.Parameters...)
+				op = &opCopy
+			}
+
+			ops[fmt.Sprintf("%s %s", strings.ToUpper(method), path)] = op
+		}
+	}
+
+	return ops
+}
+
+func getParameters(op *v3.Operation) map[string]*v3.Parameter {
+	params := map[string]*v3.Parameter{}
+
+	for _, p := range op.Parameters {
+		params[fmt.Sprintf("%s.%s", p.In, p.Name)] = p
+	}
+
+	return params
+}
+
+func getResponses(op *v3.Operation) map[string]*v3.Response {
+	responses := map[string]*v3.Response{}
+
+	if op.Responses == nil {
+		return responses
+	}
+
+	for code, response := range op.Responses.Codes {
+		responses[code] = response
+	}
+
+	if op.Responses.Default != nil {
+		responses["default"] = op.Responses.Default
+	}
+
+	return responses
+}
+
+func getComponentSchemas(doc *v3.Document) map[string]*base.SchemaProxy {
+	if doc.Components == nil || doc.Components.Schemas == nil {
+		return map[string]*base.SchemaProxy{}
+	}
+
+	return doc.Components.Schemas
+}
+
+func getSchemaReference(proxy *base.SchemaProxy) string {
+	low := proxy.GoLow()
+	if low == nil || !low.IsSchemaReference() {
+		return ""
+	}
+
+	return low.GetSchemaReference()
+}
+
+func sortedTypes(types []string) []string {
+	sorted := append([]string{}, types...)
+	sort.Strings(sorted)
+	return sorted
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
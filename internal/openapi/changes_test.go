@@ -0,0 +1,158 @@
+package openapi
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const baseDoc = `openapi: 3.0.3
+info:
+  title: Pets
+  version: 1.0.0
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      parameters:
+        - name: limit
+          in: query
+          schema:
+            type: integer
+      responses:
+        "200":
+          description: OK
+          content:
+            application/json:
+              schema:
+                type: array
+                items:
+                  $ref: "#/components/schemas/Pet"
+    post:
+      operationId: createPet
+      requestBody:
+        content:
+          application/json:
+            schema:
+              $ref: "#/components/schemas/Pet"
+      responses:
+        "200":
+          description: OK
+components:
+  schemas:
+    Pet:
+      type: object
+      required:
+        - name
+      properties:
+        name:
+          type: string
+        tag:
+          type: string
+`
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		name        string
+		current     string
+		wantLevel   ChangeLevel
+		wantChanges []Change
+	}{
+		{
+			name:      "identical documents",
+			current:   baseDoc,
+			wantLevel: ChangeLevelNone,
+		},
+		{
+			name:      "description only change is cosmetic",
+			current:   replace(baseDoc, "operationId: listPets", "operationId: listPets\n      description: Lists all pets"),
+			wantLevel: ChangeLevelCosmetic,
+		},
+		{
+			name:      "removed operation is breaking",
+			current:   cut(baseDoc, "    post:", "components:"),
+			wantLevel: ChangeLevelBreaking,
+			wantChanges: []Change{
+				{Level: ChangeLevelBreaking, Description: "removed operation `POST /pets`"},
+			},
+		},
+		{
+			name:      "field made required is breaking",
+			current:   replace(baseDoc, "        - name\n", "        - name\n        - tag\n"),
+			wantLevel: ChangeLevelBreaking,
+			wantChanges: []Change{
+				{Level: ChangeLevelBreaking, Description: "field `tag` of schema `Pet` is now required"},
+			},
+		},
+		{
+			name:      "type change is breaking",
+			current:   replace(baseDoc, "        tag:\n          type: string", "        tag:\n          type: integer"),
+			wantLevel: ChangeLevelBreaking,
+			wantChanges: []Change{
+				{Level: ChangeLevelBreaking, Description: "type of field `tag` of schema `Pet` changed from `string` to `integer`"},
+			},
+		},
+		{
+			name:      "added optional field and parameter are additive",
+			current:   replace(replace(baseDoc, "        tag:\n          type: string", "        tag:\n          type: string\n        age:\n          type: integer"), "      responses:\n        \"200\":\n          description: OK\n          content:", "        - name: offset\n          in: query\n          schema:\n            type: integer\n      responses:\n        \"200\":\n          description: OK\n          content:"),
+			wantLevel: ChangeLevelAdditive,
+			wantChanges: []Change{
+				{Level: ChangeLevelAdditive, Description: "added field `age` to schema `Pet`"},
+				{Level: ChangeLevelAdditive, Description: "added optional parameter `query.offset` to `GET /pets`"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := Compare([]byte(baseDoc), []byte(tt.current))
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantLevel, report.Level)
+			assert.Equal(t, tt.wantChanges, report.Changes)
+		})
+	}
+}
+
+func TestMerge_UsesMostSevereLevel(t *testing.T) {
+	merged := Merge(
+		&ChangeReport{Level: ChangeLevelAdditive, Changes: []Change{{Level: ChangeLevelAdditive, Description: "added schema `Owner`"}}},
+		nil,
+		&ChangeReport{Level: ChangeLevelBreaking, Changes: []Change{
+			{Level: ChangeLevelAdditive, Description: "added schema `Owner`"},
+			{Level: ChangeLevelBreaking, Description: "removed schema `Pet`"},
+		}},
+	)
+
+	assert.Equal(t, &ChangeReport{
+		Level: ChangeLevelBreaking,
+		Changes: []Change{
+			{Level: ChangeLevelAdditive, Description: "added schema `Owner`"},
+			{Level: ChangeLevelBreaking, Description: "removed schema `Pet`"},
+		},
+	}, merged)
+}
+
+func TestChangeReport_JSONRoundTrip(t *testing.T) {
+	r := ChangeReport{
+		Level:   ChangeLevelBreaking,
+		Changes: []Change{{Level: ChangeLevelBreaking, Description: "removed operation `POST /pets`"}},
+	}
+
+	data, err := r.JSON()
+	require.NoError(t, err)
+
+	parsed, err := ParseReport(data)
+	require.NoError(t, err)
+	assert.Equal(t, &r, parsed)
+}
+
+func replace(s, old, new string) string {
+	return strings.Replace(s, old, new, 1)
+}
+
+func cut(s, from, to string) string {
+	start := strings.Index(s, from)
+	end := strings.Index(s, to)
+	return s[:start] + s[end:]
+}
@@ -0,0 +1,259 @@
+package templates
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/speakeasy-api/sdk-generation-action/internal/environment"
+	"github.com/speakeasy-api/sdk-generation-action/internal/openapi"
+	"github.com/speakeasy-api/sdk-generation-action/pkg/releases"
+)
+
+// Name is the file name of a template, a file with this name in the templates directory overrides the default template
+type Name string
+
+const (
+	PRTitle         Name = "pr_title.tmpl"
+	PRBody          Name = "pr_body.tmpl"
+	CommitMessage   Name = "commit_message.tmpl"
+	ReleaseName     Name = "release_name.tmpl"
+	ReleaseBody     Name = "release_body.tmpl"
+	ReleasesSection Name = "releases_section.tmpl"
+	// LanguageCommitMessage and MetadataCommitMessage are used in place of CommitMessage when creating a commit per language
+	LanguageCommitMessage Name = "language_commit_message.tmpl"
+	MetadataCommitMessage Name = "metadata_commit_message.tmpl"
+)
+
+var defaults = map[Name]string{
+	PRTitle: `chore: speakeasy sdk regeneration - {{.WorkflowName}}`,
+	PRBody: `# Generated by Speakeasy CLI
+Based on:
+- OpenAPI Doc {{.Release.DocVersion}} {{.Release.DocLocation}}
+- Speakeasy CLI {{.Release.SpeakeasyVersion}} ({{.Release.GenerationVersion}}) https://github.com/speakeasy-api/speakeasy
+{{- if .ChangeReport}}
+
+
+{{.ChangeReport.Markdown}}{{end}}
+{{- if .Changelog}}
+
+
+## CHANGELOG
+
+{{.Changelog}}{{end}}`,
+	CommitMessage:         `ci: regenerated with OpenAPI Doc {{.Release.DocVersion}}, Speakeasy CLI {{.Release.SpeakeasyVersion}}`,
+	ReleaseName:           `{{.Language.Name}} - {{.Language.Tag}} - {{.Time}}`,
+	ReleaseBody:           `# Generated by Speakeasy CLI{{.Release}}`,
+	ReleasesSection:       `{{.Release}}`,
+	LanguageCommitMessage: `ci: regenerated {{.Language.Name}} SDK {{.Language.Version}} with OpenAPI Doc {{.Release.DocVersion}}, Speakeasy CLI {{.Release.SpeakeasyVersion}}`,
+	MetadataCommitMessage: `ci: updated release metadata for OpenAPI Doc {{.Release.DocVersion}}, Speakeasy CLI {{.Release.SpeakeasyVersion}}`,
+}
+
+// conventionalDefaults replace the defaults of the commit messages when conventional commits are enabled
+var conventionalDefaults = map[Name]string{
+	CommitMessage: `{{.CommitType}}{{with .CommitScope}}({{.}}){{end}}{{if .Breaking}}!{{end}}: regenerate with OpenAPI Doc {{.Release.DocVersion}}, Speakeasy CLI {{.Release.SpeakeasyVersion}}
+{{- range .Languages}}{{if .BumpReasons}}
+
+{{.Name}} {{with .PreviousVersion}}{{.}} -> {{end}}{{.Version}}:
+{{- range .BumpReasons}}
+- {{.}}{{end}}{{end}}{{end}}`,
+	LanguageCommitMessage: `{{.Language.CommitType}}({{.Language.Name}}){{if .Language.Breaking}}!{{end}}: regenerate {{.Language.Name}} SDK {{.Language.Version}} with OpenAPI Doc {{.Release.DocVersion}}, Speakeasy CLI {{.Release.SpeakeasyVersion}}
+{{- with .Language.BumpReasons}}
+{{range .}}
+- {{.}}{{end}}{{end}}`,
+	MetadataCommitMessage: `chore{{with .CommitScope}}({{.}}){{end}}: update release metadata for OpenAPI Doc {{.Release.DocVersion}}, Speakeasy CLI {{.Release.SpeakeasyVersion}}`,
+}
+
+var funcs = template.FuncMap{
+	"join":  strings.Join,
+	"trim":  strings.TrimSpace,
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+}
+
+// Data is the data model available to every template
+type Data struct {
+	// Release is the release being generated or released as recorded in RELEASES.md
+	Release releases.ReleasesInfo
+	// Languages are the languages in the release sorted by name
+	Languages []Language
+	// Language is the language being released or committed, only set for the release name and body and language commit message templates
+	Language *Language
+	// Bump is the largest version bump of any language in the release
+	Bump string
+	// CommitType is the conventional commit type for the release: feat for major and minor bumps, fix for patch bumps and chore otherwise
+	CommitType string
+	// CommitScope is the conventional commit scope for the release, the names of the languages in the release
+	CommitScope string
+	// Breaking is whether the release contains a major bump of a previously released language
+	Breaking bool
+	// Changelog is the changelog of the generator since the previous generation, only set for the PR body template
+	Changelog string
+	// ChangeReport classifies the changes to the OpenAPI doc, only set for the PR body template when available
+	ChangeReport *openapi.ChangeReport
+	WorkflowName string
+	Repository   string
+	// Time is the time the action was invoked
+	Time string
+}
+
+type Language struct {
+	Name            string
+	PackageName     string
+	Path            string
+	Version         string
+	PreviousVersion string
+	// Tag is the git tag the language is released under
+	Tag         string
+	Bump        string
+	BumpReasons []string
+	// CommitType and Breaking are the conventional commit type and breaking flag for the language's version bump
+	CommitType string
+	Breaking   bool
+}
+
+// NewData creates the template data for a release, previous is the release history before it and is used to determine the version bumps
+func NewData(cfg *environment.Config, release releases.ReleasesInfo, previous releases.Releases) Data {
+	data := Data{
+		Release:      release,
+		Languages:    []Language{},
+		Bump:         releases.BumpNone.String(),
+		WorkflowName: cfg.WorkflowName,
+		Repository:   cfg.Repository,
+		Time:         environment.GetInvokeTime().Format(releases.ReleaseTitleFormat),
+	}
+
+	bump := releases.BumpNone
+
+	for lang, info := range release.Languages {
+		previousVersion := info.PreviousVersion
+		if previousVersion == "" {
+			if prev, ok := previous.LatestForLanguage(lang); ok {
+				previousVersion = prev.Languages[lang].Version
+			}
+		}
+
+		langBump := releases.BumpMajor
+		breaking := false
+		if previousVersion != "" {
+			langBump = releases.GetBump(previousVersion, info.Version)
+			breaking = langBump == releases.BumpMajor
+		}
+		if breaking {
+			data.Breaking = true
+		}
+
+		if langBump > bump {
+			bump = langBump
+		}
+
+		data.Languages = append(data.Languages, Language{
+			Name:            lang,
+			PackageName:     info.PackageName,
+			Path:            info.Path,
+			Version:         info.Version,
+			PreviousVersion: previousVersion,
+			Tag:             info.Tag(),
+			Bump:            langBump.String(),
+			BumpReasons:     info.BumpReasons,
+			CommitType:      commitType(langBump),
+			Breaking:        breaking,
+		})
+	}
+
+	sort.Slice(data.Languages, func(i, j int) bool {
+		return data.Languages[i].Name < data.Languages[j].Name
+	})
+	data.Bump = bump.String()
+
+	names := []string{}
+	for _, l := range data.Languages {
+		names = append(names, l.Name)
+	}
+	data.CommitScope = strings.Join(names, ",")
+	data.CommitType = commitType(bump)
+
+	return data
+}
+
+func commitType(bump releases.Bump) string {
+	switch bump {
+	case releases.BumpMajor, releases.BumpMinor:
+		return "feat"
+	case releases.BumpPatch:
+		return "fix"
+	default:
+		return "chore"
+	}
+}
+
+// ForLanguage returns a copy of the data for releasing or committing the given language
+func (d Data) ForLanguage(lang string) Data {
+	for i := range d.Languages {
+		if d.Languages[i].Name == lang {
+			d.Language = &d.Languages[i]
+		}
+	}
+
+	return d
+}
+
+// Templates renders the text generated by the action, using the templates found in the repo in place of the defaults
+type Templates struct {
+	templates map[Name]*template.Template
+}
+
+// Load loads the templates from dir, any template without a file in dir uses its default
+func Load(dir string, conventionalCommits bool) (*Templates, error) {
+	t := &Templates{
+		templates: map[Name]*template.Template{},
+	}
+
+	for name, text := range defaults {
+		if conventional, ok := conventionalDefaults[name]; ok && conventionalCommits {
+			text = conventional
+		}
+
+		data, err := os.ReadFile(filepath.Join(dir, string(name)))
+		if err == nil {
+			text = string(data)
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("failed to read template %s: %w", name, err)
+		}
+
+		tmpl, err := template.New(string(name)).Funcs(funcs).Option("missingkey=error").Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse template %s: %w", name, err)
+		}
+
+		t.templates[name] = tmpl
+	}
+
+	return t, nil
+}
+
+func (t *Templates) Render(name Name, data Data) (string, error) {
+	tmpl, ok := t.templates[name]
+	if !ok {
+		return "", fmt.Errorf("unknown template %s", name)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render template %s: %w", name, err)
+	}
+
+	return buf.String(), nil
+}
+
+// ReleasesRenderer returns a renderer for the sections of the RELEASES.md file
+func (t *Templates) ReleasesRenderer(cfg *environment.Config) releases.Renderer {
+	return func(release releases.ReleasesInfo, previous releases.Releases) (string, error) {
+		return t.Render(ReleasesSection, NewData(cfg, release, previous))
+	}
+}
@@ -0,0 +1,233 @@
+package templates
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/speakeasy-api/sdk-generation-action/internal/environment"
+	"github.com/speakeasy-api/sdk-generation-action/internal/openapi"
+	"github.com/speakeasy-api/sdk-generation-action/pkg/releases"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testCfg = &environment.Config{WorkflowName: "generate", Repository: "test/repo"}
+
+func getTestRelease() releases.ReleasesInfo {
+	return releases.ReleasesInfo{
+		ReleaseTitle:      "2023-02-10 12:00:00",
+		DocVersion:        "1.1.0",
+		DocLocation:       "https://example.com/openapi.yaml",
+		SpeakeasyVersion:  "1.20.0",
+		GenerationVersion: "2.1.0",
+		Languages: map[string]releases.LanguageReleaseInfo{
+			"python": {PackageName: "org-package", Path: "python", Version: "1.1.0", BumpReasons: []string{"OpenAPI doc changes classified as additive"}},
+			"go":     {PackageName: "github.com/org/repo", Path: ".", Version: "2.0.0"},
+		},
+	}
+}
+
+func getTestPrevious() releases.Releases {
+	return releases.Releases{
+		{
+			ReleaseTitle: "2023-01-10 12:00:00",
+			Languages: map[string]releases.LanguageReleaseInfo{
+				"python": {PackageName: "org-package", Path: "python", Version: "1.0.3"},
+			},
+		},
+	}
+}
+
+func TestNewData_Success(t *testing.T) {
+	data := NewData(testCfg, getTestRelease(), getTestPrevious())
+
+	assert.Equal(t, "major", data.Bump)
+	assert.Equal(t, "generate", data.WorkflowName)
+	assert.Equal(t, []Language{
+		{Name: "go", PackageName: "github.com/org/repo", Path: ".", Version: "2.0.0", Tag: "v2.0.0", Bump: "major", CommitType: "feat"},
+		{Name: "python", PackageName: "org-package", Path: "python", Version: "1.1.0", PreviousVersion: "1.0.3", Tag: "python/v1.1.0", Bump: "minor", BumpReasons: []string{"OpenAPI doc changes classified as additive"}, CommitType: "feat"},
+	}, data.Languages)
+
+	assert.Equal(t, "feat", data.CommitType)
+	assert.Equal(t, "go,python", data.CommitScope)
+	assert.False(t, data.Breaking, "the first release of a language is not a breaking change")
+
+	assert.Nil(t, data.Language)
+	assert.Equal(t, "python/v1.1.0", data.ForLanguage("python").Language.Tag)
+}
+
+func TestDefaults_MatchLegacyOutput(t *testing.T) {
+	os.Setenv("GITHUB_REPOSITORY", "test/repo")
+
+	tmpls, err := Load(t.TempDir(), false)
+	require.NoError(t, err)
+
+	release := getTestRelease()
+	data := NewData(testCfg, release, getTestPrevious())
+
+	title, err := tmpls.Render(PRTitle, data)
+	require.NoError(t, err)
+	assert.Equal(t, "chore: speakeasy sdk regeneration - generate", title)
+
+	body, err := tmpls.Render(PRBody, data)
+	require.NoError(t, err)
+	assert.Equal(t, `# Generated by Speakeasy CLI
+Based on:
+- OpenAPI Doc 1.1.0 https://example.com/openapi.yaml
+- Speakeasy CLI 1.20.0 (2.1.0) https://github.com/speakeasy-api/speakeasy`, body)
+
+	report := &openapi.ChangeReport{Level: openapi.ChangeLevelAdditive}
+	data.ChangeReport = report
+	data.Changelog = "## Generator changes\n"
+
+	body, err = tmpls.Render(PRBody, data)
+	require.NoError(t, err)
+	assert.Equal(t, `# Generated by Speakeasy CLI
+Based on:
+- OpenAPI Doc 1.1.0 https://example.com/openapi.yaml
+- Speakeasy CLI 1.20.0 (2.1.0) https://github.com/speakeasy-api/speakeasy
+
+
+`+report.Markdown()+`
+
+
+## CHANGELOG
+
+## Generator changes
+`, body)
+
+	message, err := tmpls.Render(CommitMessage, data)
+	require.NoError(t, err)
+	assert.Equal(t, "ci: regenerated with OpenAPI Doc 1.1.0, Speakeasy CLI 1.20.0", message)
+
+	name, err := tmpls.Render(ReleaseName, data.ForLanguage("python"))
+	require.NoError(t, err)
+	assert.Equal(t, "python - python/v1.1.0 - "+environment.GetInvokeTime().Format(releases.ReleaseTitleFormat), name)
+
+	releaseBody, err := tmpls.Render(ReleaseBody, data.ForLanguage("python"))
+	require.NoError(t, err)
+	assert.Equal(t, "# Generated by Speakeasy CLI"+release.String(), releaseBody)
+
+	section, err := tmpls.ReleasesRenderer(testCfg)(release, getTestPrevious())
+	require.NoError(t, err)
+	assert.Equal(t, release.String(), section)
+}
+
+func TestConventionalCommitMessage(t *testing.T) {
+	tmpls, err := Load(t.TempDir(), true)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name      string
+		languages map[string]releases.LanguageReleaseInfo
+		want      string
+	}{
+		{
+			name: "major",
+			languages: map[string]releases.LanguageReleaseInfo{
+				"python": {Version: "2.0.0", PreviousVersion: "1.4.1", BumpReasons: []string{"OpenAPI doc changes classified as breaking"}},
+			},
+			want: "feat(python)!: regenerate with OpenAPI Doc 1.1.0, Speakeasy CLI 1.20.0\n\npython 1.4.1 -> 2.0.0:\n- OpenAPI doc changes classified as breaking",
+		},
+		{
+			name: "minor and patch",
+			languages: map[string]releases.LanguageReleaseInfo{
+				"go":     {Version: "1.3.0", PreviousVersion: "1.2.9"},
+				"python": {Version: "1.4.2", PreviousVersion: "1.4.1"},
+			},
+			want: "feat(go,python): regenerate with OpenAPI Doc 1.1.0, Speakeasy CLI 1.20.0",
+		},
+		{
+			name: "patch",
+			languages: map[string]releases.LanguageReleaseInfo{
+				"go": {Version: "1.2.10", PreviousVersion: "1.2.9"},
+			},
+			want: "fix(go): regenerate with OpenAPI Doc 1.1.0, Speakeasy CLI 1.20.0",
+		},
+		{
+			name: "no languages",
+			want: "chore: regenerate with OpenAPI Doc 1.1.0, Speakeasy CLI 1.20.0",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			release := getTestRelease()
+			release.Languages = tt.languages
+
+			message, err := tmpls.Render(CommitMessage, NewData(testCfg, release, nil))
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, message)
+		})
+	}
+}
+
+func TestLanguageCommitMessages(t *testing.T) {
+	release := getTestRelease()
+	release.Languages = map[string]releases.LanguageReleaseInfo{
+		"go":     {Version: "1.2.10", PreviousVersion: "1.2.9"},
+		"python": {Version: "2.0.0", PreviousVersion: "1.4.1", BumpReasons: []string{"OpenAPI doc changes classified as breaking"}},
+	}
+	data := NewData(testCfg, release, nil)
+
+	tmpls, err := Load(t.TempDir(), false)
+	require.NoError(t, err)
+
+	message, err := tmpls.Render(LanguageCommitMessage, data.ForLanguage("python"))
+	require.NoError(t, err)
+	assert.Equal(t, "ci: regenerated python SDK 2.0.0 with OpenAPI Doc 1.1.0, Speakeasy CLI 1.20.0", message)
+
+	message, err = tmpls.Render(MetadataCommitMessage, data)
+	require.NoError(t, err)
+	assert.Equal(t, "ci: updated release metadata for OpenAPI Doc 1.1.0, Speakeasy CLI 1.20.0", message)
+
+	tmpls, err = Load(t.TempDir(), true)
+	require.NoError(t, err)
+
+	message, err = tmpls.Render(LanguageCommitMessage, data.ForLanguage("python"))
+	require.NoError(t, err)
+	assert.Equal(t, "feat(python)!: regenerate python SDK 2.0.0 with OpenAPI Doc 1.1.0, Speakeasy CLI 1.20.0\n\n- OpenAPI doc changes classified as breaking", message)
+
+	message, err = tmpls.Render(LanguageCommitMessage, data.ForLanguage("go"))
+	require.NoError(t, err)
+	assert.Equal(t, "fix(go): regenerate go SDK 1.2.10 with OpenAPI Doc 1.1.0, Speakeasy CLI 1.20.0", message)
+
+	message, err = tmpls.Render(MetadataCommitMessage, data)
+	require.NoError(t, err)
+	assert.Equal(t, "chore(go,python): update release metadata for OpenAPI Doc 1.1.0, Speakeasy CLI 1.20.0", message)
+}
+
+func TestLoad_Override_Success(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, string(CommitMessage)), []byte(`chore(sdk): {{range .Languages}}{{.Name}}@{{.Version}} {{end}}[{{upper .Bump}}]`), 0o644))
+
+	tmpls, err := Load(dir, false)
+	require.NoError(t, err)
+
+	message, err := tmpls.Render(CommitMessage, NewData(testCfg, getTestRelease(), getTestPrevious()))
+	require.NoError(t, err)
+	assert.Equal(t, "chore(sdk): go@2.0.0 python@1.1.0 [MAJOR]", message)
+
+	title, err := tmpls.Render(PRTitle, Data{WorkflowName: "generate"})
+	require.NoError(t, err)
+	assert.Equal(t, "chore: speakeasy sdk regeneration - generate", title)
+}
+
+func TestLoad_InvalidTemplate_Error(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, string(PRTitle)), []byte(`{{.WorkflowName`), 0o644))
+
+	_, err := Load(dir, false)
+	assert.ErrorContains(t, err, "failed to parse template pr_title.tmpl")
+}
+
+func TestRender_UnknownField_Error(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, string(PRTitle)), []byte(`{{.Workflow}}`), 0o644))
+
+	tmpls, err := Load(dir, false)
+	require.NoError(t, err)
+
+	_, err = tmpls.Render(PRTitle, Data{})
+	assert.ErrorContains(t, err, "failed to render template pr_title.tmpl")
+}
@@ -7,12 +7,21 @@ import (
 
 	"github.com/speakeasy-api/sdk-generation-action/internal/actions"
 	"github.com/speakeasy-api/sdk-generation-action/internal/environment"
+	"github.com/speakeasy-api/sdk-generation-action/internal/logging"
 	"golang.org/x/exp/slices"
 )
 
 func main() {
-	if environment.IsDebugMode() {
-		envs := os.Environ()
+	cfg, err := environment.Load(os.Getenv)
+	if err != nil {
+		fmt.Printf("::error title=invalid configuration::%v\n", err)
+		os.Exit(1)
+	}
+
+	logging.SetDebug(cfg.Debug)
+
+	if cfg.Debug {
+		envs := environment.Redact(os.Environ())
 		slices.SortFunc(envs, func(i, j string) bool {
 			iKey, iValue, _ := strings.Cut(i, "=")
 			jKey, jValue, _ := strings.Cut(j, "=")
@@ -25,14 +34,17 @@ func main() {
 		}
 	}
 
-	var err error
-	switch environment.GetAction() {
+	switch cfg.Action {
 	case environment.ActionGenerate:
-		err = actions.Generate()
+		err = actions.Generate(cfg)
 	case environment.ActionFinalize:
-		err = actions.Finalize()
+		err = actions.Finalize(cfg)
 	case environment.ActionRelease:
-		err = actions.Release()
+		err = actions.Release(cfg)
+	case environment.ActionCleanup:
+		err = actions.Cleanup(cfg)
+	case environment.ActionReleaseLock:
+		err = actions.ReleaseLock(cfg)
 	}
 
 	if err != nil {
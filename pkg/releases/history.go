@@ -0,0 +1,184 @@
+package releases
+
+import (
+	"time"
+
+	"github.com/hashicorp/go-version"
+)
+
+// ReleaseTitleFormat is the time format used for the titles of releases created by the action
+const ReleaseTitleFormat = "2006-01-02 15:04:05"
+
+// Releases is a release history ordered from oldest to newest
+type Releases []ReleasesInfo
+
+// Latest returns the most recent release
+func (r Releases) Latest() (*ReleasesInfo, bool) {
+	if len(r) == 0 {
+		return nil, false
+	}
+
+	return &r[len(r)-1], true
+}
+
+// LatestForLanguage returns the most recent release containing a release of the given language
+func (r Releases) LatestForLanguage(lang string) (*ReleasesInfo, bool) {
+	for i := len(r) - 1; i >= 0; i-- {
+		if _, ok := r[i].Languages[lang]; ok {
+			return &r[i], true
+		}
+	}
+
+	return nil, false
+}
+
+// FindByVersion returns the release that shipped the given SDK version, if lang is empty any language is matched
+func (r Releases) FindByVersion(lang, sdkVersion string) (*ReleasesInfo, bool) {
+	want, err := version.NewVersion(sdkVersion)
+	if err != nil {
+		return nil, false
+	}
+
+	for i := len(r) - 1; i >= 0; i-- {
+		for l, info := range r[i].Languages {
+			if lang != "" && l != lang {
+				continue
+			}
+
+			v, err := version.NewVersion(info.Version)
+			if err == nil && v.Equal(want) {
+				return &r[i], true
+			}
+		}
+	}
+
+	return nil, false
+}
+
+// Bump is the size of a version bump between two SDK releases
+type Bump int
+
+const (
+	BumpNone Bump = iota
+	BumpPatch
+	BumpMinor
+	BumpMajor
+)
+
+func (b Bump) String() string {
+	switch b {
+	case BumpPatch:
+		return "patch"
+	case BumpMinor:
+		return "minor"
+	case BumpMajor:
+		return "major"
+	default:
+		return "none"
+	}
+}
+
+// ParseBump parses the name of a bump as returned by Bump.String
+func ParseBump(name string) (Bump, bool) {
+	for _, bump := range []Bump{BumpNone, BumpPatch, BumpMinor, BumpMajor} {
+		if bump.String() == name {
+			return bump, true
+		}
+	}
+
+	return BumpNone, false
+}
+
+// Bump returns the largest bump of any language in the release compared to the version it was regenerated from.
+// A language without a previous version, or a version that can't be parsed, is treated as a major bump.
+func (r ReleasesInfo) Bump() Bump {
+	bump := BumpNone
+
+	for _, info := range r.Languages {
+		if langBump := GetBump(info.PreviousVersion, info.Version); langBump > bump {
+			bump = langBump
+		}
+	}
+
+	return bump
+}
+
+// LatestBump returns the largest bump of any language in the latest release compared to that language's previous release.
+// The first release of a language, or a version that can't be parsed, is treated as a major bump.
+func (r Releases) LatestBump() Bump {
+	latest, ok := r.Latest()
+	if !ok {
+		return BumpNone
+	}
+
+	previous := r[:len(r)-1]
+	bump := BumpNone
+
+	for lang, info := range latest.Languages {
+		langBump := BumpMajor
+
+		if prev, ok := previous.LatestForLanguage(lang); ok {
+			langBump = GetBump(prev.Languages[lang].Version, info.Version)
+		}
+
+		if langBump > bump {
+			bump = langBump
+		}
+	}
+
+	return bump
+}
+
+// GetBump returns the size of the bump between two versions, versions that can't be parsed are treated as a major bump
+func GetBump(from, to string) Bump {
+	fromV, err := version.NewVersion(from)
+	if err != nil {
+		return BumpMajor
+	}
+	toV, err := version.NewVersion(to)
+	if err != nil {
+		return BumpMajor
+	}
+
+	fromSegments := fromV.Segments()
+	toSegments := toV.Segments()
+
+	switch {
+	case toSegments[0] != fromSegments[0]:
+		return BumpMajor
+	case toSegments[1] != fromSegments[1]:
+		return BumpMinor
+	case toSegments[2] != fromSegments[2]:
+		return BumpPatch
+	default:
+		return BumpNone
+	}
+}
+
+// Between returns the releases released between from and to inclusive, releases without a timestamp as their title are skipped
+func (r Releases) Between(from, to time.Time) Releases {
+	matches := Releases{}
+
+	for _, info := range r {
+		released, ok := info.ReleaseTime()
+		if !ok {
+			continue
+		}
+
+		if !released.Before(from) && !released.After(to) {
+			matches = append(matches, info)
+		}
+	}
+
+	return matches
+}
+
+// ReleaseTime parses the time of the release from its title
+func (r ReleasesInfo) ReleaseTime() (time.Time, bool) {
+	t, err := time.Parse(ReleaseTitleFormat, r.ReleaseTitle)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	return t, true
+}
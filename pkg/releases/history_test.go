@@ -0,0 +1,191 @@
+package releases_test
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/speakeasy-api/sdk-generation-action/pkg/releases"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func getTestReleases() releases.Releases {
+	return releases.Releases{
+		{
+			ReleaseTitle:     "2023-01-10 12:00:00",
+			DocVersion:       "1.0.0",
+			DocLocation:      "https://example.com",
+			SpeakeasyVersion: "1.10.0",
+			Languages: map[string]releases.LanguageReleaseInfo{
+				"python":     {PackageName: "org-package", Path: "python", Version: "1.0.0", URL: "https://pypi.org/project/org-package/1.0.0"},
+				"typescript": {PackageName: "@org/package", Path: "typescript", Version: "1.0.0", URL: "https://www.npmjs.com/package/@org/package/v/1.0.0"},
+			},
+		},
+		{
+			ReleaseTitle:     "2023-02-10 12:00:00",
+			DocVersion:       "1.1.0",
+			DocLocation:      "https://example.com",
+			SpeakeasyVersion: "1.11.0",
+			Languages: map[string]releases.LanguageReleaseInfo{
+				"python": {PackageName: "org-package", Path: "python", Version: "1.1.0", URL: "https://pypi.org/project/org-package/1.1.0"},
+			},
+		},
+		{
+			ReleaseTitle:     "Version 1.2.0",
+			DocVersion:       "1.2.0",
+			DocLocation:      "https://example.com",
+			SpeakeasyVersion: "1.12.0",
+			Languages: map[string]releases.LanguageReleaseInfo{
+				"python": {PackageName: "org-package", Path: "python", Version: "1.2.0", URL: "https://pypi.org/project/org-package/1.2.0"},
+			},
+		},
+	}
+}
+
+func TestReleases_ParseAllReleases_Success(t *testing.T) {
+	os.Setenv("GITHUB_REPOSITORY", "test/repo")
+
+	want := getTestReleases()
+
+	data := ""
+	for _, r := range want {
+		data += r.String()
+	}
+
+	all, err := releases.ParseAllReleases(data)
+	require.NoError(t, err)
+	assert.Equal(t, want, all)
+}
+
+func TestReleases_ParseAllReleases_NoReleases(t *testing.T) {
+	_, err := releases.ParseAllReleases("# Releases\n\nNothing to see here")
+	assert.Error(t, err)
+}
+
+func TestReleases_LatestForLanguage(t *testing.T) {
+	all := getTestReleases()
+
+	r, ok := all.LatestForLanguage("typescript")
+	require.True(t, ok)
+	assert.Equal(t, "2023-01-10 12:00:00", r.ReleaseTitle)
+
+	r, ok = all.LatestForLanguage("python")
+	require.True(t, ok)
+	assert.Equal(t, "Version 1.2.0", r.ReleaseTitle)
+
+	_, ok = all.LatestForLanguage("go")
+	assert.False(t, ok)
+}
+
+func TestReleases_FindByVersion(t *testing.T) {
+	all := getTestReleases()
+
+	r, ok := all.FindByVersion("python", "1.1.0")
+	require.True(t, ok)
+	assert.Equal(t, "2023-02-10 12:00:00", r.ReleaseTitle)
+
+	r, ok = all.FindByVersion("", "v1.0.0")
+	require.True(t, ok)
+	assert.Equal(t, "2023-01-10 12:00:00", r.ReleaseTitle)
+
+	_, ok = all.FindByVersion("typescript", "1.1.0")
+	assert.False(t, ok)
+}
+
+func TestReleases_Between(t *testing.T) {
+	all := getTestReleases()
+
+	from := time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2023, 12, 31, 0, 0, 0, 0, time.UTC)
+
+	between := all.Between(from, to)
+	require.Len(t, between, 1)
+	assert.Equal(t, "2023-02-10 12:00:00", between[0].ReleaseTitle)
+}
+
+func TestReleases_LatestBump(t *testing.T) {
+	release := func(versions map[string]string) releases.ReleasesInfo {
+		info := releases.ReleasesInfo{Languages: map[string]releases.LanguageReleaseInfo{}}
+		for lang, v := range versions {
+			info.Languages[lang] = releases.LanguageReleaseInfo{Version: v}
+		}
+		return info
+	}
+
+	tests := []struct {
+		name     string
+		releases releases.Releases
+		want     releases.Bump
+	}{
+		{
+			name: "no releases",
+			want: releases.BumpNone,
+		},
+		{
+			name:     "first release",
+			releases: releases.Releases{release(map[string]string{"go": "0.1.0"})},
+			want:     releases.BumpMajor,
+		},
+		{
+			name:     "patch",
+			releases: releases.Releases{release(map[string]string{"go": "1.2.3"}), release(map[string]string{"go": "1.2.4"})},
+			want:     releases.BumpPatch,
+		},
+		{
+			name: "largest bump of any language",
+			releases: releases.Releases{
+				release(map[string]string{"go": "1.2.3", "python": "2.0.0"}),
+				release(map[string]string{"go": "1.2.4", "python": "2.1.0"}),
+			},
+			want: releases.BumpMinor,
+		},
+		{
+			name: "previous release of language further back",
+			releases: releases.Releases{
+				release(map[string]string{"go": "1.2.3"}),
+				release(map[string]string{"python": "2.0.0"}),
+				release(map[string]string{"go": "2.0.0"}),
+			},
+			want: releases.BumpMajor,
+		},
+		{
+			name: "new language",
+			releases: releases.Releases{
+				release(map[string]string{"go": "1.2.3"}),
+				release(map[string]string{"go": "1.2.4", "python": "0.0.1"}),
+			},
+			want: releases.BumpMajor,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.releases.LatestBump())
+		})
+	}
+}
+
+func TestReleasesInfo_Bump(t *testing.T) {
+	info := releases.ReleasesInfo{Languages: map[string]releases.LanguageReleaseInfo{
+		"go":     {PreviousVersion: "1.2.3", Version: "1.2.4"},
+		"python": {PreviousVersion: "2.0.0", Version: "3.0.0"},
+	}}
+	assert.Equal(t, releases.BumpMajor, info.Bump())
+
+	info.Languages["python"] = releases.LanguageReleaseInfo{PreviousVersion: "2.0.0", Version: "2.1.0"}
+	assert.Equal(t, releases.BumpMinor, info.Bump())
+
+	info.Languages["typescript"] = releases.LanguageReleaseInfo{Version: "0.0.1"}
+	assert.Equal(t, releases.BumpMajor, info.Bump(), "a language without a previous version is a major bump")
+}
+
+func TestParseBump(t *testing.T) {
+	for _, bump := range []releases.Bump{releases.BumpNone, releases.BumpPatch, releases.BumpMinor, releases.BumpMajor} {
+		parsed, ok := releases.ParseBump(bump.String())
+		assert.True(t, ok)
+		assert.Equal(t, bump, parsed)
+	}
+
+	_, ok := releases.ParseBump("huge")
+	assert.False(t, ok)
+}
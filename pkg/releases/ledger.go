@@ -0,0 +1,133 @@
+package releases
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/speakeasy-api/sdk-generation-action/internal/environment"
+	"github.com/speakeasy-api/sdk-generation-action/internal/logging"
+)
+
+// LedgerPath is the location of the releases ledger relative to the directory containing RELEASES.md
+const LedgerPath = ".speakeasy/releases.json"
+
+// Ledger is the machine-readable history of every release and the source of truth for the release steps. Each new release
+// is rendered from it and appended to RELEASES.md, releases already in RELEASES.md aren't re-rendered.
+type Ledger struct {
+	Releases Releases `json:"releases"`
+}
+
+// NewLedgerFromMarkdown backfills a ledger by parsing the releases in an existing RELEASES.md file
+func NewLedgerFromMarkdown(data string) *Ledger {
+	releases, err := ParseAllReleases(data)
+	if err != nil {
+		return &Ledger{}
+	}
+
+	return &Ledger{
+		Releases: releases,
+	}
+}
+
+// LoadLedger loads the releases ledger from the given directory, returning an error wrapping os.ErrNotExist if there is no ledger
+func LoadLedger(dir string) (*Ledger, error) {
+	ledgerPath := GetLedgerPath(dir)
+
+	logging.Debug("Reading releases ledger at %s", ledgerPath)
+
+	data, err := os.ReadFile(ledgerPath)
+	if err != nil {
+		return nil, fmt.Errorf("error reading releases ledger: %w", err)
+	}
+
+	return ParseLedger(data)
+}
+
+func ParseLedger(data []byte) (*Ledger, error) {
+	var ledger Ledger
+	if err := json.Unmarshal(data, &ledger); err != nil {
+		return nil, fmt.Errorf("error parsing releases ledger: %w", err)
+	}
+
+	return &ledger, nil
+}
+
+func (l Ledger) Save(dir string) error {
+	ledgerPath := GetLedgerPath(dir)
+
+	logging.Debug("Updating releases ledger at %s", ledgerPath)
+
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshalling releases ledger: %w", err)
+	}
+
+	if err := os.MkdirAll(path.Dir(ledgerPath), os.ModePerm); err != nil {
+		return fmt.Errorf("error creating releases ledger directory: %w", err)
+	}
+
+	if err := os.WriteFile(ledgerPath, append(data, '\n'), 0o600); err != nil {
+		return fmt.Errorf("error writing releases ledger: %w", err)
+	}
+
+	return nil
+}
+
+// Renderer renders a release as a section of the RELEASES.md file, previous is the release history before it
+type Renderer func(release ReleasesInfo, previous Releases) (string, error)
+
+// renderRelease renders the release at index i as a section of the RELEASES.md file using render, or ReleasesInfo.String if nil
+func (l Ledger) renderRelease(i int, render Renderer) (string, error) {
+	release := l.Releases[i]
+
+	if render == nil {
+		return release.String(), nil
+	}
+
+	section, err := render(release, l.Releases[:i])
+	if err != nil {
+		return "", fmt.Errorf("error rendering release %s: %w", release.ReleaseTitle, err)
+	}
+
+	return section, nil
+}
+
+func GetLedgerPath(dir string) string {
+	baseDir := environment.GetBaseDir()
+
+	return path.Join(baseDir, "repo", dir, LedgerPath)
+}
+
+func loadOrMigrateLedger(dir string) (*Ledger, error) {
+	ledger, err := LoadLedger(dir)
+	if err == nil {
+		return ledger, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(GetReleasesPath(dir))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return &Ledger{}, nil
+		}
+
+		return nil, fmt.Errorf("error reading releases file: %w", err)
+	}
+
+	ledger = NewLedgerFromMarkdown(string(data))
+
+	logging.Info("Backfilled releases ledger with %d releases from %s", len(ledger.Releases), GetReleasesPath(dir))
+
+	// RELEASES.md is only appended to, so sections that couldn't be parsed are kept there but are missing from the history used by the release steps
+	if sections := strings.Count("\n"+string(data), "\n## "); sections > len(ledger.Releases) {
+		logging.Info("%d sections of %s couldn't be parsed as releases so are missing from the releases ledger", sections-len(ledger.Releases), GetReleasesPath(dir))
+	}
+
+	return ledger, nil
+}
@@ -0,0 +1,86 @@
+package releases_test
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/speakeasy-api/sdk-generation-action/pkg/releases"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLedger_BackfillFromMarkdown_Success(t *testing.T) {
+	os.Setenv("GITHUB_REPOSITORY", "test/repo")
+
+	r1 := releases.ReleasesInfo{
+		ReleaseTitle:      "2023-02-22 10:00:00",
+		DocVersion:        "1.0.0",
+		DocLocation:       "https://example.com",
+		SpeakeasyVersion:  "1.10.0",
+		GenerationVersion: "2.10.0",
+		Languages: map[string]releases.LanguageReleaseInfo{
+			"python": {
+				PackageName: "org-package",
+				Path:        "python",
+				Version:     "1.0.0",
+				URL:         "https://pypi.org/project/org-package/1.0.0",
+			},
+		},
+	}
+
+	r2 := releases.ReleasesInfo{
+		ReleaseTitle:      "2023-03-01 10:00:00",
+		DocVersion:        "1.1.0",
+		DocLocation:       "https://example.com",
+		SpeakeasyVersion:  "1.11.0",
+		GenerationVersion: "2.11.0",
+		Languages: map[string]releases.LanguageReleaseInfo{
+			"python": {
+				PackageName: "org-package",
+				Path:        "python",
+				Version:     "1.1.0",
+				URL:         "https://pypi.org/project/org-package/1.1.0",
+			},
+			"typescript": {
+				PackageName: "@org/package",
+				Path:        "typescript",
+				Version:     "1.1.0",
+				URL:         "https://www.npmjs.com/package/@org/package/v/1.1.0",
+			},
+		},
+	}
+
+	markdown := "# Releases" + r1.String() + r2.String()
+
+	ledger := releases.NewLedgerFromMarkdown(markdown)
+	assert.Equal(t, releases.Releases{r1, r2}, ledger.Releases)
+}
+
+func TestLedger_JSONSerialization_Success(t *testing.T) {
+	ledger := releases.Ledger{
+		Releases: releases.Releases{
+			{
+				ReleaseTitle:     "2023-02-22 10:00:00",
+				DocVersion:       "1.0.0",
+				DocLocation:      "https://example.com",
+				SpeakeasyVersion: "1.10.0",
+				Languages: map[string]releases.LanguageReleaseInfo{
+					"go": {
+						PackageName: "github.com/test/repo",
+						Path:        ".",
+						Version:     "1.0.0",
+					},
+				},
+			},
+		},
+	}
+
+	data, err := json.Marshal(ledger)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"releases":[{"releaseTitle":"2023-02-22 10:00:00","docVersion":"1.0.0","speakeasyVersion":"1.10.0","docLocation":"https://example.com","languages":{"go":{"packageName":"github.com/test/repo","path":".","version":"1.0.0"}}}]}`, string(data))
+
+	var parsed releases.Ledger
+	require.NoError(t, json.Unmarshal(data, &parsed))
+	assert.Equal(t, ledger, parsed)
+}
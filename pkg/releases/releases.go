@@ -1,10 +1,12 @@
 package releases
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path"
 	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/speakeasy-api/sdk-generation-action/internal/environment"
@@ -12,25 +14,45 @@ import (
 )
 
 type LanguageReleaseInfo struct {
-	PackageName string
-	Path        string
-	Version     string
-	URL         string
+	PackageName string `json:"packageName"`
+	Path        string `json:"path"`
+	Version     string `json:"version"`
+	URL         string `json:"url,omitempty"`
+	// PreviousVersion and BumpReasons record the version before and why it was bumped, only available for releases recorded in the releases ledger
+	PreviousVersion string   `json:"previousVersion,omitempty"`
+	BumpReasons     []string `json:"bumpReasons,omitempty"`
+}
+
+// Tag returns the git tag the language is released under, prefixed with its path in mono repos
+func (l LanguageReleaseInfo) Tag() string {
+	tag := "v" + l.Version
+	if l.Path != "." {
+		tag = fmt.Sprintf("%s/%s", l.Path, tag)
+	}
+
+	return tag
 }
 
 type ReleasesInfo struct {
-	ReleaseTitle      string
-	DocVersion        string
-	SpeakeasyVersion  string
-	GenerationVersion string
-	DocLocation       string
-	Languages         map[string]LanguageReleaseInfo
+	ReleaseTitle      string                         `json:"releaseTitle"`
+	DocVersion        string                         `json:"docVersion"`
+	SpeakeasyVersion  string                         `json:"speakeasyVersion"`
+	GenerationVersion string                         `json:"generationVersion,omitempty"`
+	DocLocation       string                         `json:"docLocation"`
+	Languages         map[string]LanguageReleaseInfo `json:"languages"`
 }
 
 func (r ReleasesInfo) String() string {
 	releasesOutput := []string{}
 
-	for lang, info := range r.Languages {
+	langs := make([]string, 0, len(r.Languages))
+	for lang := range r.Languages {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+
+	for _, lang := range langs {
+		info := r.Languages[lang]
 		pkgID := ""
 		pkgURL := ""
 
@@ -39,12 +61,7 @@ func (r ReleasesInfo) String() string {
 			pkgID = "Go"
 			repoPath := os.Getenv("GITHUB_REPOSITORY")
 
-			tag := fmt.Sprintf("v%s", info.Version)
-			if info.Path != "." {
-				tag = fmt.Sprintf("%s/%s", info.Path, tag)
-			}
-
-			pkgURL = fmt.Sprintf("https://github.com/%s/releases/tag/%s", repoPath, tag)
+			pkgURL = fmt.Sprintf("https://github.com/%s/releases/tag/%s", repoPath, info.Tag())
 		case "typescript":
 			pkgID = "NPM"
 			pkgURL = fmt.Sprintf("https://www.npmjs.com/package/%s/v/%s", info.PackageName, info.Version)
@@ -78,7 +95,30 @@ Based on:
 - Speakeasy CLI %s (%s) https://github.com/speakeasy-api/speakeasy%s`, "\n\n", r.ReleaseTitle, r.DocVersion, r.DocLocation, r.SpeakeasyVersion, r.GenerationVersion, strings.Join(releasesOutput, "\n"))
 }
 
+// UpdateReleasesFile records the release in the releases ledger and appends it to the RELEASES.md file. The rest of RELEASES.md,
+// such as its header, hand-written notes or releases the ledger couldn't be backfilled from, is left untouched.
 func UpdateReleasesFile(releaseInfo ReleasesInfo, dir string) error {
+	return UpdateReleasesFileWithRenderer(releaseInfo, dir, nil)
+}
+
+// UpdateReleasesFileWithRenderer is UpdateReleasesFile rendering the release in RELEASES.md using render, or ReleasesInfo.String if nil
+func UpdateReleasesFileWithRenderer(releaseInfo ReleasesInfo, dir string, render Renderer) error {
+	ledger, err := loadOrMigrateLedger(dir)
+	if err != nil {
+		return err
+	}
+
+	ledger.Releases = append(ledger.Releases, releaseInfo)
+
+	section, err := ledger.renderRelease(len(ledger.Releases)-1, render)
+	if err != nil {
+		return err
+	}
+
+	if err := ledger.Save(dir); err != nil {
+		return err
+	}
+
 	releasesPath := GetReleasesPath(dir)
 
 	logging.Debug("Updating releases file at %s", releasesPath)
@@ -89,8 +129,7 @@ func UpdateReleasesFile(releaseInfo ReleasesInfo, dir string) error {
 	}
 	defer f.Close()
 
-	_, err = f.WriteString(releaseInfo.String())
-	if err != nil {
+	if _, err := f.WriteString(section); err != nil {
 		return fmt.Errorf("error writing to releases file: %w", err)
 	}
 
@@ -107,6 +146,18 @@ var (
 )
 
 func GetLastReleaseInfo(dir string) (*ReleasesInfo, error) {
+	ledger, err := LoadLedger(dir)
+	if err == nil {
+		if len(ledger.Releases) == 0 {
+			return nil, fmt.Errorf("no releases found in releases ledger")
+		}
+
+		return &ledger.Releases[len(ledger.Releases)-1], nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
 	releasesPath := GetReleasesPath(dir)
 
 	logging.Debug("Reading releases file at %s", releasesPath)
@@ -119,15 +170,63 @@ func GetLastReleaseInfo(dir string) (*ReleasesInfo, error) {
 	return ParseReleases(string(data))
 }
 
+// GetReleases returns the full release history for the given directory, preferring the releases ledger over RELEASES.md
+func GetReleases(dir string) (Releases, error) {
+	ledger, err := LoadLedger(dir)
+	if err == nil {
+		return ledger.Releases, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	releasesPath := GetReleasesPath(dir)
+
+	logging.Debug("Reading releases file at %s", releasesPath)
+
+	data, err := os.ReadFile(releasesPath)
+	if err != nil {
+		return nil, fmt.Errorf("error reading releases file: %w", err)
+	}
+
+	return ParseAllReleases(string(data))
+}
+
 func ParseReleases(data string) (*ReleasesInfo, error) {
 	releases := strings.Split(data, "\n\n")
 
 	lastRelease := releases[len(releases)-1]
 
-	matches := releaseInfoRegex.FindStringSubmatch(lastRelease)
+	info := parseRelease(lastRelease)
+	if info == nil {
+		return nil, fmt.Errorf("error parsing last release info")
+	}
+
+	return info, nil
+}
+
+// ParseAllReleases parses every release entry in the RELEASES.md file in the order they were released, skipping any content that isn't a release
+func ParseAllReleases(data string) (Releases, error) {
+	entries := Releases{}
+
+	for _, release := range strings.Split(data, "\n\n") {
+		if info := parseRelease(release); info != nil {
+			entries = append(entries, *info)
+		}
+	}
+
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("no releases found")
+	}
+
+	return entries, nil
+}
+
+func parseRelease(release string) *ReleasesInfo {
+	matches := releaseInfoRegex.FindStringSubmatch(release)
 
 	if len(matches) < 5 {
-		return nil, fmt.Errorf("error parsing last release info")
+		return nil
 	}
 
 	genVersion := ""
@@ -146,7 +245,7 @@ func ParseReleases(data string) (*ReleasesInfo, error) {
 		Languages:         map[string]LanguageReleaseInfo{},
 	}
 
-	npmMatches := npmReleaseRegex.FindStringSubmatch(lastRelease)
+	npmMatches := npmReleaseRegex.FindStringSubmatch(release)
 
 	if len(npmMatches) == 5 {
 		info.Languages["typescript"] = LanguageReleaseInfo{
@@ -157,7 +256,7 @@ func ParseReleases(data string) (*ReleasesInfo, error) {
 		}
 	}
 
-	pypiMatches := pypiReleaseRegex.FindStringSubmatch(lastRelease)
+	pypiMatches := pypiReleaseRegex.FindStringSubmatch(release)
 
 	if len(pypiMatches) == 5 {
 		info.Languages["python"] = LanguageReleaseInfo{
@@ -168,7 +267,7 @@ func ParseReleases(data string) (*ReleasesInfo, error) {
 		}
 	}
 
-	goMatches := goReleaseRegex.FindStringSubmatch(lastRelease)
+	goMatches := goReleaseRegex.FindStringSubmatch(release)
 
 	if len(goMatches) == 5 {
 		packageName := goMatches[3]
@@ -186,7 +285,7 @@ func ParseReleases(data string) (*ReleasesInfo, error) {
 		}
 	}
 
-	composerMatches := composerReleaseRegex.FindStringSubmatch(lastRelease)
+	composerMatches := composerReleaseRegex.FindStringSubmatch(release)
 
 	if len(composerMatches) == 5 {
 		info.Languages["php"] = LanguageReleaseInfo{
@@ -197,7 +296,7 @@ func ParseReleases(data string) (*ReleasesInfo, error) {
 		}
 	}
 
-	mavenMatches := mavenReleaseRegex.FindStringSubmatch(lastRelease)
+	mavenMatches := mavenReleaseRegex.FindStringSubmatch(release)
 
 	if len(mavenMatches) == 6 {
 		groupID := mavenMatches[3]
@@ -210,7 +309,7 @@ func ParseReleases(data string) (*ReleasesInfo, error) {
 		}
 	}
 
-	return info, nil
+	return info
 }
 
 func GetReleasesPath(dir string) string {
@@ -2,6 +2,7 @@ package releases_test
 
 import (
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/speakeasy-api/sdk-generation-action/pkg/releases"
@@ -242,3 +243,29 @@ Based on:
 		},
 	}, *info)
 }
+
+func TestReleases_String_LanguagesSorted(t *testing.T) {
+	os.Setenv("GITHUB_REPOSITORY", "test/repo")
+
+	r := releases.ReleasesInfo{
+		ReleaseTitle:     "2023-02-22",
+		DocVersion:       "9.8.7",
+		DocLocation:      "https://example.com",
+		SpeakeasyVersion: "6.6.6",
+		Languages: map[string]releases.LanguageReleaseInfo{
+			"typescript": {PackageName: "@org/package", Path: "typescript", Version: "1.2.3"},
+			"python":     {PackageName: "org-package", Path: "python", Version: "1.2.3"},
+			"go":         {PackageName: "github.com/test/repo/go", Path: "go", Version: "1.2.3"},
+		},
+	}
+
+	expected := r.String()
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, expected, r.String())
+	}
+
+	goIndex := strings.Index(expected, "[Go v1.2.3]")
+	pypiIndex := strings.Index(expected, "[PyPI v1.2.3]")
+	npmIndex := strings.Index(expected, "[NPM v1.2.3]")
+	assert.True(t, goIndex < pypiIndex && pypiIndex < npmIndex)
+}
@@ -0,0 +1,824 @@
+package e2e_test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var actionBinary string
+
+func TestMain(m *testing.M) {
+	os.Exit(runTests(m))
+}
+
+func runTests(m *testing.M) int {
+	if runtime.GOOS == "windows" {
+		fmt.Println("skipping end to end tests: the stub speakeasy cli requires a POSIX shell")
+		return 0
+	}
+
+	if _, err := exec.LookPath("git"); err != nil {
+		fmt.Println("skipping end to end tests: git not found")
+		return 0
+	}
+
+	dir, err := os.MkdirTemp("", "sdk-generation-action-e2e")
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+	defer os.RemoveAll(dir)
+
+	actionBinary = filepath.Join(dir, "action")
+
+	// The binary is built once, so the Speakeasy CLI releases API it's built with forwards to the fake GitHub of the running test
+	releasesAPI := httptest.NewServer(http.HandlerFunc(serveSpeakeasyReleases))
+	defer releasesAPI.Close()
+
+	build := exec.Command("go", "build", "-ldflags", "-X github.com/speakeasy-api/sdk-generation-action/internal/git.speakeasyReleasesAPIURL="+releasesAPI.URL, "-o", actionBinary, "../..")
+	if out, err := build.CombinedOutput(); err != nil {
+		fmt.Printf("failed to build action: %v\n%s\n", err, out)
+		return 1
+	}
+
+	return m.Run()
+}
+
+func TestE2E_DirectMode_Success(t *testing.T) {
+	h := newHarness(t)
+	initialCommit := h.head("main")
+
+	outputs, err := h.run(map[string]string{
+		"action":         "generate",
+		"mode":           "direct",
+		"create_release": "true",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "true", outputs["go_regenerated"])
+	assert.Equal(t, ".", outputs["go_directory"])
+	assert.Equal(t, speakeasyVersion, outputs["resolved_speakeasy_version"])
+
+	branchName := outputs["branch_name"]
+	require.NotEmpty(t, branchName)
+	assert.Contains(t, h.branches(), branchName)
+	assert.Equal(t, initialCommit, h.head("main"), "generate should not touch main in direct mode")
+
+	outputs, err = h.run(map[string]string{
+		"action":               "finalize",
+		"mode":                 "direct",
+		"create_release":       "true",
+		"branch_name":          branchName,
+		"previous_gen_version": outputs["previous_gen_version"],
+	})
+	require.NoError(t, err)
+
+	mergedCommit := h.head("main")
+	assert.Equal(t, mergedCommit, outputs["commit_hash"])
+	assert.NotEqual(t, initialCommit, mergedCommit)
+	assert.Equal(t, []string{"main"}, h.branches(), "regen branch should be deleted after merging")
+	assert.Equal(t, openAPIDoc, h.remoteGit("show", "main:sdk.txt")+"\n")
+	assert.Contains(t, h.remoteGit("show", "main:RELEASES.md"), "[Go v1.0.0]")
+	assert.Contains(t, h.remoteGit("show", "main:gen.yaml"), "version: 1.0.0")
+
+	releases := h.github.Releases()
+	require.Len(t, releases, 1)
+	assert.Equal(t, "v1.0.0", releases[0].TagName)
+	assert.Empty(t, h.github.PullRequests())
+
+	h.setPushEvent(initialCommit, mergedCommit)
+
+	outputs, err = h.run(map[string]string{
+		"action": "release",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "true", outputs["go_regenerated"])
+	assert.Equal(t, ".", outputs["go_directory"])
+	assert.Len(t, h.github.Releases(), 1)
+}
+
+func TestE2E_ReleasesFileEdits_Success(t *testing.T) {
+	h := newHarness(t)
+
+	existing := "# Releases\n\nHand-written notes about the SDKs.\n\n## 2022-01-01 00:00:00\nReleased by hand before the action was adopted."
+	h.commitFiles(map[string]string{"RELEASES.md": existing})
+
+	outputs, err := h.run(map[string]string{
+		"action":         "generate",
+		"mode":           "direct",
+		"create_release": "true",
+	})
+	require.NoError(t, err)
+
+	_, err = h.run(map[string]string{
+		"action":               "finalize",
+		"mode":                 "direct",
+		"create_release":       "true",
+		"branch_name":          outputs["branch_name"],
+		"previous_gen_version": outputs["previous_gen_version"],
+	})
+	require.NoError(t, err)
+
+	releasesFile := h.remoteGit("show", "main:RELEASES.md")
+	assert.True(t, strings.HasPrefix(releasesFile, existing), "the existing contents of RELEASES.md should be kept")
+	assert.Contains(t, releasesFile, "[Go v1.0.0]")
+}
+
+func TestE2E_ReleaseRerun_Success(t *testing.T) {
+	h := newHarness(t)
+	initialCommit := h.head("main")
+
+	outputs, err := h.run(map[string]string{
+		"action":         "generate",
+		"mode":           "direct",
+		"create_release": "true",
+	})
+	require.NoError(t, err)
+
+	outputs, err = h.run(map[string]string{
+		"action":               "finalize",
+		"mode":                 "direct",
+		"create_release":       "true",
+		"branch_name":          outputs["branch_name"],
+		"previous_gen_version": outputs["previous_gen_version"],
+	})
+	require.NoError(t, err)
+	assert.Equal(t, `[{"language":"go","tag":"v1.0.0","status":"created"}]`, outputs["release_results"])
+
+	releases := h.github.Releases()
+	require.Len(t, releases, 1)
+	body := releases[0].Body
+
+	h.github.EditRelease("v1.0.0", "edited")
+	h.setPushEvent(initialCommit, h.head("main"))
+
+	outputs, err = h.run(map[string]string{
+		"action":         "release",
+		"create_release": "true",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, `[{"language":"go","tag":"v1.0.0","status":"skipped"}]`, outputs["release_results"])
+	assert.Equal(t, "edited", h.github.Releases()[0].Body)
+
+	_, err = h.run(map[string]string{
+		"action":                  "release",
+		"create_release":          "true",
+		"existing_release_policy": "fail",
+	})
+	assert.Error(t, err)
+
+	outputs, err = h.run(map[string]string{
+		"action":                  "release",
+		"create_release":          "true",
+		"existing_release_policy": "update",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, `[{"language":"go","tag":"v1.0.0","status":"updated"}]`, outputs["release_results"])
+
+	releases = h.github.Releases()
+	require.Len(t, releases, 1)
+	assert.Equal(t, body, releases[0].Body)
+}
+
+func TestE2E_DryRun_Success(t *testing.T) {
+	for _, mode := range []string{"direct", "pr"} {
+		t.Run(mode, func(t *testing.T) {
+			h := newHarness(t)
+			initialCommit := h.head("main")
+
+			outputs, err := h.run(map[string]string{
+				"action":  "generate",
+				"mode":    mode,
+				"dry_run": "true",
+			})
+			require.NoError(t, err)
+
+			branchName := outputs["branch_name"]
+			require.NotEmpty(t, branchName)
+			assert.Equal(t, []string{"main"}, h.branches(), "the branch should not be pushed in dry run mode")
+
+			_, err = h.run(map[string]string{
+				"action":               "finalize",
+				"mode":                 mode,
+				"dry_run":              "true",
+				"create_release":       "true",
+				"branch_name":          branchName,
+				"previous_gen_version": outputs["previous_gen_version"],
+			})
+			require.NoError(t, err)
+
+			assert.Equal(t, initialCommit, h.head("main"))
+			assert.Equal(t, []string{"main"}, h.branches())
+			assert.Empty(t, h.github.PullRequests())
+			assert.Empty(t, h.github.Releases())
+		})
+	}
+}
+
+func TestE2E_ReleaseEmptyLedger_Error(t *testing.T) {
+	h := newHarness(t)
+	initialCommit := h.head("main")
+
+	h.commitFiles(map[string]string{
+		"RELEASES.md":              "# Releases\n",
+		".speakeasy/releases.json": `{"releases":[]}`,
+	})
+	h.setPushEvent(initialCommit, h.head("main"))
+
+	_, err := h.run(map[string]string{
+		"action":         "release",
+		"create_release": "true",
+	})
+	assert.ErrorContains(t, err, "exit status 1", "the action should fail rather than panic")
+	assert.Empty(t, h.github.Releases())
+}
+
+func TestE2E_PRMode_Success(t *testing.T) {
+	h := newHarness(t)
+	initialCommit := h.head("main")
+
+	cacheDir := t.TempDir()
+
+	outputs, err := h.run(map[string]string{
+		"action":              "generate",
+		"mode":                "pr",
+		"create_release":      "true",
+		"speakeasy_cache_dir": cacheDir,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "true", outputs["go_regenerated"])
+
+	branchName := outputs["branch_name"]
+	require.NotEmpty(t, branchName)
+
+	outputs, err = h.run(map[string]string{
+		"action":                "finalize",
+		"mode":                  "pr",
+		"branch_name":           branchName,
+		"previous_gen_version":  outputs["previous_gen_version"],
+		"speakeasy_cache_dir":   cacheDir,
+		"pr_labels":             "sdk, automated",
+		"pr_reviewers":          "octocat",
+		"pr_team_reviewers":     "sdk-owners",
+		"pr_draft":              "true",
+		"auto_merge":            "true",
+		"auto_merge_skip_major": "true",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "false", outputs["auto_merge_enabled"], "the first release is a major bump so should not be auto-merged")
+	assert.Equal(t, 1, h.github.CLIDownloads(), "the cached speakeasy cli should be reused")
+
+	prs := h.github.PullRequests()
+	require.Len(t, prs, 1)
+	assert.Equal(t, "chore: speakeasy sdk regeneration - test", prs[0].Title)
+	assert.Equal(t, branchName, prs[0].Head.Ref)
+	assert.Equal(t, "refs/heads/main", prs[0].Base.Ref)
+	assert.Contains(t, prs[0].Body, "Speakeasy CLI "+speakeasyVersion)
+	assert.True(t, prs[0].Draft)
+	assert.Equal(t, []string{"sdk", "automated"}, prs[0].Labels)
+	assert.Equal(t, []string{"octocat", "team:sdk-owners"}, prs[0].Reviewers)
+	assert.Empty(t, prs[0].AutoMerge)
+	assert.Equal(t, initialCommit, h.head("main"), "finalize should not touch main in pr mode")
+	assert.Contains(t, h.branches(), branchName)
+
+	h.github.LabelPullRequest(prs[0].Number, "needs-review")
+
+	// Regenerating while the PR is open should reuse its branch and update the PR
+	outputs, err = h.run(map[string]string{
+		"action":            "generate",
+		"mode":              "pr",
+		"create_release":    "true",
+		"force":             "true",
+		"speakeasy_version": ">=1.19 <2",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, branchName, outputs["branch_name"])
+	assert.Equal(t, speakeasyVersion, outputs["resolved_speakeasy_version"])
+
+	outputs, err = h.run(map[string]string{
+		"action":               "finalize",
+		"mode":                 "pr",
+		"branch_name":          branchName,
+		"previous_gen_version": outputs["previous_gen_version"],
+		"version_bump":         outputs["version_bump"],
+		"pr_labels":            "sdk, regenerated",
+		"pr_assignees":         "hubot",
+		"auto_merge":           "true",
+		"auto_merge_method":    "squash",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "true", outputs["auto_merge_enabled"])
+
+	prs = h.github.PullRequests()
+	require.Len(t, prs, 1)
+	assert.False(t, prs[0].Draft, "the PR should be marked ready for review as pr_draft is no longer set")
+	assert.Equal(t, []string{"sdk", "needs-review", "regenerated"}, prs[0].Labels, "labels should be re-synced on update, leaving labels added by others")
+	assert.Empty(t, prs[0].Reviewers, "reviewers no longer configured should be removed")
+	assert.Equal(t, []string{"hubot"}, prs[0].Assignees)
+	assert.Equal(t, "SQUASH", prs[0].AutoMerge)
+
+	h.mergePR(branchName)
+	mergedCommit := h.head("main")
+	assert.Contains(t, h.remoteGit("show", "main:RELEASES.md"), "[Go v1.0.0]")
+
+	h.setPushEvent(initialCommit, mergedCommit)
+
+	outputs, err = h.run(map[string]string{
+		"action":         "release",
+		"create_release": "true",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "true", outputs["go_regenerated"])
+
+	releases := h.github.Releases()
+	require.Len(t, releases, 1)
+	assert.Equal(t, "v1.0.0", releases[0].TagName)
+}
+
+func TestE2E_AutoMergeUnpublishedMajor_Success(t *testing.T) {
+	h := newHarness(t)
+
+	// The Go SDK isn't published without create_release, so isn't recorded in the release history
+	outputs, err := h.run(map[string]string{
+		"action": "generate",
+		"mode":   "pr",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "major", outputs["version_bump"], "the first generation of an SDK is a major bump")
+
+	outputs, err = h.run(map[string]string{
+		"action":                "finalize",
+		"mode":                  "pr",
+		"branch_name":           outputs["branch_name"],
+		"previous_gen_version":  outputs["previous_gen_version"],
+		"version_bump":          outputs["version_bump"],
+		"auto_merge":            "true",
+		"auto_merge_skip_major": "true",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "false", outputs["auto_merge_enabled"])
+
+	prs := h.github.PullRequests()
+	require.Len(t, prs, 1)
+	assert.Empty(t, prs[0].AutoMerge)
+}
+
+func TestE2E_Templates_Success(t *testing.T) {
+	h := newHarness(t)
+	h.commitFiles(map[string]string{
+		".speakeasy/templates/commit_message.tmpl": "feat: {{range .Languages}}{{.Name}} {{.Version}} ({{.Bump}}){{end}}",
+		".speakeasy/templates/release_name.tmpl":   "{{.Language.PackageName}} {{.Language.Tag}}",
+		".speakeasy/templates/release_body.tmpl":   "{{range .Language.BumpReasons}}- {{.}}\n{{end}}",
+	})
+
+	outputs, err := h.run(map[string]string{
+		"action":         "generate",
+		"mode":           "direct",
+		"create_release": "true",
+	})
+	require.NoError(t, err)
+
+	branchName := outputs["branch_name"]
+	assert.Equal(t, "feat: go 1.0.0 (major)", h.remoteGit("log", "-1", "--format=%s", branchName))
+
+	_, err = h.run(map[string]string{
+		"action":               "finalize",
+		"mode":                 "direct",
+		"create_release":       "true",
+		"branch_name":          branchName,
+		"previous_gen_version": outputs["previous_gen_version"],
+	})
+	require.NoError(t, err)
+
+	releases := h.github.Releases()
+	require.Len(t, releases, 1)
+	assert.Equal(t, "github.com/test/repo v1.0.0", releases[0].Name)
+	assert.Contains(t, releases[0].Body, "- Generation version changed detected: 0.0.0 > 2.0.0\n")
+}
+
+func TestE2E_ConventionalCommits_Success(t *testing.T) {
+	h := newHarness(t)
+
+	outputs, err := h.run(map[string]string{
+		"action":               "generate",
+		"mode":                 "direct",
+		"conventional_commits": "true",
+		"commit_author_name":   "SDK Bot",
+		"commit_author_email":  "sdk-bot@example.com",
+		"committer_email":      "ci@example.com",
+	})
+	require.NoError(t, err)
+
+	branchName := outputs["branch_name"]
+	assert.Equal(t, "feat(go)!: regenerate with OpenAPI Doc 1.0.0, Speakeasy CLI "+speakeasyVersion, h.remoteGit("log", "-1", "--format=%s", branchName))
+	assert.Equal(t, "SDK Bot <sdk-bot@example.com>", h.remoteGit("log", "-1", "--format=%an <%ae>", branchName))
+	assert.Equal(t, "SDK Bot <ci@example.com>", h.remoteGit("log", "-1", "--format=%cn <%ce>", branchName))
+}
+
+func TestE2E_PerLanguageCommits_Success(t *testing.T) {
+	h := newHarness(t)
+	initialCommit := h.head("main")
+
+	outputs, err := h.run(map[string]string{
+		"action":               "generate",
+		"mode":                 "direct",
+		"per_language_commits": "true",
+	})
+	require.NoError(t, err)
+
+	branchName := outputs["branch_name"]
+	assert.Equal(t, "ci: updated release metadata for OpenAPI Doc 1.0.0, Speakeasy CLI "+speakeasyVersion+"\n"+
+		"ci: regenerated go SDK 1.0.0 with OpenAPI Doc 1.0.0, Speakeasy CLI "+speakeasyVersion,
+		h.remoteGit("log", "--format=%s", initialCommit+".."+branchName))
+
+	assert.ElementsMatch(t, []string{"RELEASES.md", ".speakeasy/releases.json", "gen.yaml"}, strings.Fields(h.remoteGit("show", "--format=", "--name-only", branchName)))
+	sdkFiles := strings.Fields(h.remoteGit("show", "--format=", "--name-only", branchName+"~1"))
+	assert.NotContains(t, sdkFiles, "gen.yaml")
+	assert.NotContains(t, sdkFiles, ".speakeasy/releases.json")
+}
+
+func TestE2E_MergeStrategies_Success(t *testing.T) {
+	tests := []struct {
+		strategy string
+		assert   func(t *testing.T, h *harness, baseCommit string)
+	}{
+		{
+			strategy: "merge",
+			assert: func(t *testing.T, h *harness, baseCommit string) {
+				assert.Equal(t, baseCommit, h.remoteGit("rev-parse", "main^1"))
+				assert.Equal(t, "speakeasybot", h.remoteGit("log", "-1", "--format=%an", "main"))
+			},
+		},
+		{
+			strategy: "squash",
+			assert: func(t *testing.T, h *harness, baseCommit string) {
+				assert.Equal(t, baseCommit, h.remoteGit("rev-parse", "main^1"))
+				assert.Equal(t, "1", h.remoteGit("rev-list", "--count", "--no-walk", "--parents", "main", "--max-parents=1"))
+				assert.Equal(t, "ci: regenerated with OpenAPI Doc 1.0.0, Speakeasy CLI "+speakeasyVersion, h.remoteGit("log", "-1", "--format=%s", "main"))
+			},
+		},
+		{
+			strategy: "rebase",
+			assert: func(t *testing.T, h *harness, baseCommit string) {
+				assert.Equal(t, baseCommit, h.remoteGit("rev-parse", "main^1"))
+				assert.Equal(t, "1", h.remoteGit("rev-list", "--count", "--no-walk", "--parents", "main", "--max-parents=1"))
+				assert.Equal(t, "ci: regenerated with OpenAPI Doc 1.0.0, Speakeasy CLI "+speakeasyVersion, h.remoteGit("log", "-1", "--format=%s", "main"))
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.strategy, func(t *testing.T) {
+			h := newHarness(t)
+
+			outputs, err := h.run(map[string]string{
+				"action": "generate",
+				"mode":   "direct",
+			})
+			require.NoError(t, err)
+
+			// main moves on while the SDK is being compiled
+			h.commitFiles(map[string]string{"docs/README.md": "# Docs"})
+			baseCommit := h.head("main")
+
+			outputs, err = h.run(map[string]string{
+				"action":               "finalize",
+				"mode":                 "direct",
+				"merge_strategy":       tt.strategy,
+				"branch_name":          outputs["branch_name"],
+				"previous_gen_version": outputs["previous_gen_version"],
+			})
+			require.NoError(t, err)
+
+			assert.Equal(t, h.head("main"), outputs["commit_hash"])
+			assert.Equal(t, "# Docs", h.remoteGit("show", "main:docs/README.md"))
+			assert.Equal(t, openAPIDoc, h.remoteGit("show", "main:sdk.txt")+"\n")
+			assert.Equal(t, []string{"main"}, h.branches())
+			tt.assert(t, h, baseCommit)
+		})
+	}
+}
+
+func TestE2E_MergeFastForwardOnly_Error(t *testing.T) {
+	h := newHarness(t)
+
+	outputs, err := h.run(map[string]string{
+		"action": "generate",
+		"mode":   "direct",
+	})
+	require.NoError(t, err)
+
+	h.commitFiles(map[string]string{"docs/README.md": "# Docs"})
+	baseCommit := h.head("main")
+
+	_, err = h.run(map[string]string{
+		"action":               "finalize",
+		"mode":                 "direct",
+		"merge_strategy":       "ff-only",
+		"branch_name":          outputs["branch_name"],
+		"previous_gen_version": outputs["previous_gen_version"],
+	})
+	assert.Error(t, err)
+	assert.Equal(t, baseCommit, h.head("main"))
+}
+
+func TestE2E_MergeRetry_Success(t *testing.T) {
+	h := newHarness(t)
+
+	outputs, err := h.run(map[string]string{
+		"action": "generate",
+		"mode":   "direct",
+	})
+	require.NoError(t, err)
+	branchName := outputs["branch_name"]
+
+	// A conflicting change is made to the generated SDK on main
+	h.commitFiles(map[string]string{"sdk.txt": "edited by hand"})
+	baseCommit := h.head("main")
+
+	outputs, err = h.run(map[string]string{
+		"action":               "finalize",
+		"mode":                 "direct",
+		"merge_strategy":       "ff-only",
+		"merge_retries":        "1",
+		"branch_name":          branchName,
+		"previous_gen_version": outputs["previous_gen_version"],
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, h.head("main"), outputs["commit_hash"])
+	assert.Equal(t, baseCommit, h.remoteGit("rev-parse", "main^1"), "the SDK should be regenerated on top of the updated main")
+	assert.Equal(t, openAPIDoc, h.remoteGit("show", "main:sdk.txt")+"\n")
+	assert.Equal(t, []string{"main"}, h.branches())
+}
+
+func TestE2E_MergeConflictFallback_Success(t *testing.T) {
+	h := newHarness(t)
+
+	outputs, err := h.run(map[string]string{
+		"action": "generate",
+		"mode":   "direct",
+	})
+	require.NoError(t, err)
+	branchName := outputs["branch_name"]
+
+	h.commitFiles(map[string]string{"sdk.txt": "edited by hand"})
+	baseCommit := h.head("main")
+
+	outputs, err = h.run(map[string]string{
+		"action":                  "finalize",
+		"mode":                    "direct",
+		"merge_conflict_fallback": "pr",
+		"create_release":          "true",
+		"branch_name":             branchName,
+		"previous_gen_version":    outputs["previous_gen_version"],
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, baseCommit, h.head("main"))
+	assert.Contains(t, h.branches(), branchName)
+	assert.Empty(t, outputs["commit_hash"])
+	assert.Empty(t, h.github.Releases())
+
+	prs := h.github.PullRequests()
+	require.Len(t, prs, 1)
+	assert.Equal(t, branchName, prs[0].Head.Ref)
+	assert.Equal(t, prs[0].HTMLURL, outputs["pr_url"])
+	assert.Contains(t, prs[0].Body, "## Merge conflict")
+	assert.Contains(t, prs[0].Body, "- `sdk.txt`")
+}
+
+func TestE2E_ConcurrencyLock_Success(t *testing.T) {
+	h := newHarness(t)
+
+	outputs, err := h.run(map[string]string{
+		"action":           "generate",
+		"mode":             "direct",
+		"concurrency_lock": "true",
+	})
+	require.NoError(t, err)
+
+	lockOwner, expiresAt := h.lock()
+	assert.Equal(t, runID, lockOwner, "the lock should be held until finalize")
+	assert.WithinDuration(t, time.Now().Add(time.Hour), expiresAt, time.Minute)
+
+	_, err = h.run(map[string]string{
+		"action":               "finalize",
+		"mode":                 "direct",
+		"concurrency_lock":     "true",
+		"branch_name":          outputs["branch_name"],
+		"previous_gen_version": outputs["previous_gen_version"],
+	})
+	require.NoError(t, err)
+
+	lockOwner, _ = h.lock()
+	assert.Empty(t, lockOwner, "finalize should release the lock")
+	assert.Equal(t, []string{"main"}, h.branches())
+}
+
+func TestE2E_ConcurrencyLockHeld_Error(t *testing.T) {
+	h := newHarness(t)
+	expiresAt := time.Now().Add(time.Hour).Truncate(time.Second)
+	h.setLock("2", expiresAt)
+
+	_, err := h.run(map[string]string{
+		"action":           "generate",
+		"mode":             "direct",
+		"concurrency_lock": "true",
+		"lock_timeout":     "1s",
+	})
+	assert.Error(t, err)
+
+	lockOwner, lockExpiresAt := h.lock()
+	assert.Equal(t, "2", lockOwner)
+	assert.True(t, expiresAt.Equal(lockExpiresAt))
+	assert.Equal(t, []string{"main"}, h.branches(), "no branch should be created without the lock")
+}
+
+func TestE2E_ConcurrencyLockStale_Success(t *testing.T) {
+	h := newHarness(t)
+	h.setLock("2", time.Now().Add(-time.Minute))
+
+	outputs, err := h.run(map[string]string{
+		"action":           "generate",
+		"mode":             "direct",
+		"concurrency_lock": "true",
+		"lock_timeout":     "1s",
+		"lock_expiry":      "30m",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "true", outputs["go_regenerated"])
+
+	lockOwner, expiresAt := h.lock()
+	assert.Equal(t, runID, lockOwner)
+	assert.WithinDuration(t, time.Now().Add(30*time.Minute), expiresAt, time.Minute)
+}
+
+func TestE2E_ReleaseLock_Success(t *testing.T) {
+	h := newHarness(t)
+
+	_, err := h.run(map[string]string{
+		"action":           "generate",
+		"mode":             "direct",
+		"concurrency_lock": "true",
+	})
+	require.NoError(t, err)
+
+	lockOwner, _ := h.lock()
+	require.Equal(t, runID, lockOwner)
+
+	// Compiling the SDKs failed so finalize is skipped and the lock is released on its own
+	_, err = h.run(map[string]string{
+		"action":           "release-lock",
+		"concurrency_lock": "true",
+	})
+	require.NoError(t, err)
+
+	lockOwner, _ = h.lock()
+	assert.Empty(t, lockOwner, "release-lock should release the lock")
+}
+
+func TestE2E_ReleaseLockOtherRun_Success(t *testing.T) {
+	h := newHarness(t)
+	expiresAt := time.Now().Add(time.Hour).Truncate(time.Second)
+	h.setLock("2", expiresAt)
+
+	_, err := h.run(map[string]string{
+		"action":           "release-lock",
+		"concurrency_lock": "true",
+	})
+	require.NoError(t, err)
+
+	lockOwner, lockExpiresAt := h.lock()
+	assert.Equal(t, "2", lockOwner, "a lock held by another run should be left alone")
+	assert.True(t, expiresAt.Equal(lockExpiresAt))
+}
+
+func TestE2E_Cleanup_Success(t *testing.T) {
+	h := newHarness(t)
+
+	regenBranch := func(age time.Duration) string {
+		name := fmt.Sprintf("speakeasy-sdk-regen-%d", time.Now().Add(-age).Unix())
+		h.remoteGit("branch", name, "main")
+		return name
+	}
+
+	abandoned := regenBranch(48 * time.Hour)
+	superseded := regenBranch(47 * time.Hour)
+	latest := regenBranch(46 * time.Hour)
+	inProgress := regenBranch(time.Hour)
+	otherWorkflow := regenBranch(45 * time.Hour)
+	h.remoteGit("branch", "feature", "main")
+
+	marker := `<!-- speakeasy-sdk-regen workflow="test" base="refs/heads/main" -->`
+	supersededPR := h.github.OpenPullRequest(superseded, "chore: regenerate", marker)
+	latestPR := h.github.OpenPullRequest(latest, "chore: regenerate", marker)
+	// The PR of another regeneration workflow in the repo is newer but isn't superseded by this workflow's
+	otherWorkflowPR := h.github.OpenPullRequest(otherWorkflow, "chore: regenerate v2", `<!-- speakeasy-sdk-regen workflow="test-v2" base="refs/heads/main" -->`)
+
+	outputs, err := h.run(map[string]string{
+		"action":          "cleanup",
+		"cleanup_max_age": "24h",
+	})
+	require.NoError(t, err)
+
+	assert.ElementsMatch(t, []string{"main", "feature", latest, inProgress, otherWorkflow}, h.branches())
+	assert.Equal(t, abandoned+","+superseded, outputs["deleted_branches"])
+	assert.Equal(t, fmt.Sprint(supersededPR), outputs["closed_prs"])
+
+	prs := h.github.PullRequests()
+	require.Len(t, prs, 3)
+	assert.Equal(t, "open", prs[otherWorkflowPR-1].State)
+	assert.Equal(t, "closed", prs[supersededPR-1].State)
+	assert.Equal(t, []string{fmt.Sprintf("Superseded by #%d, closing as only the latest regeneration PR is kept up to date.", latestPR)}, prs[supersededPR-1].Comments)
+	assert.Equal(t, "open", prs[latestPR-1].State)
+}
+
+func TestE2E_GithubApp_Success(t *testing.T) {
+	h := newHarness(t)
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	h.github.SetAppKey(&key.PublicKey)
+
+	app := map[string]string{
+		"github_access_token":        "",
+		"github_app_id":              fmt.Sprint(appID),
+		"github_app_installation_id": fmt.Sprint(appInstallationID),
+		"github_app_private_key":     string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})),
+	}
+	withApp := func(inputs map[string]string) map[string]string {
+		for k, v := range app {
+			inputs[k] = v
+		}
+		return inputs
+	}
+
+	outputs, err := h.run(withApp(map[string]string{
+		"action": "generate",
+		"mode":   "pr",
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, "true", outputs["go_regenerated"])
+
+	_, err = h.run(withApp(map[string]string{
+		"action":               "finalize",
+		"mode":                 "pr",
+		"branch_name":          outputs["branch_name"],
+		"previous_gen_version": outputs["previous_gen_version"],
+	}))
+	require.NoError(t, err)
+
+	require.Len(t, h.github.PullRequests(), 1)
+	assert.Equal(t, 2, h.github.InstallationTokens(), "each run should mint a single installation token")
+	assert.Equal(t, []string{"Bearer ghs_installation1", "Bearer ghs_installation2"}, h.github.Authorizations())
+}
+
+func TestE2E_LocalCLI_Success(t *testing.T) {
+	h := newHarness(t)
+
+	cliPath := filepath.Join(t.TempDir(), "speakeasy")
+	require.NoError(t, os.WriteFile(cliPath, []byte(stubSpeakeasy), 0o755))
+
+	outputs, err := h.run(map[string]string{
+		"action":            "generate",
+		"mode":              "direct",
+		"speakeasy_path":    cliPath,
+		"speakeasy_version": speakeasyVersion,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "true", outputs["go_regenerated"])
+	assert.Equal(t, 0, h.github.CLIDownloads())
+
+	_, err = h.run(map[string]string{
+		"action":            "generate",
+		"mode":              "direct",
+		"speakeasy_path":    cliPath,
+		"speakeasy_version": "1.0.0",
+	})
+	assert.Error(t, err)
+}
+
+func TestE2E_InvalidInput_Error(t *testing.T) {
+	h := newHarness(t)
+
+	_, err := h.run(map[string]string{
+		"action": "generate",
+		"mode":   "merge",
+	})
+	assert.Error(t, err)
+	assert.Equal(t, []string{"main"}, h.branches())
+}
@@ -0,0 +1,932 @@
+package e2e_test
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	owner            = "test"
+	repoName         = "repo"
+	speakeasyVersion = "1.20.0"
+	// runID is the id of the workflow run every run of the action belongs to
+	runID = "1"
+	// appID and appInstallationID identify the GitHub App installation used when testing GitHub App authentication
+	appID             = 1234
+	appInstallationID = 5678
+)
+
+// stubSpeakeasy mimics the subset of the Speakeasy CLI used by the action, "generating" an SDK by copying the OpenAPI doc into the output dir
+const stubSpeakeasy = `#!/bin/sh
+case "$*" in
+"--version")
+	echo "speakeasy version ` + speakeasyVersion + `"
+	;;
+"generate sdk --help")
+	echo "available options: [go, typescript, python]"
+	;;
+"generate sdk version")
+	echo "Version: v2.0.0"
+	;;
+"generate sdk changelog"*)
+	echo "## Generator changes"
+	;;
+"validate config"*)
+	echo "config valid"
+	;;
+"generate sdk -s"*)
+	mkdir -p "$8" && cp "$4" "$8/sdk.txt"
+	;;
+*)
+	echo "unsupported command: $*" >&2
+	exit 1
+	;;
+esac
+`
+
+const genYAML = `configVersion: 1.0.0
+generation:
+  sdkClassName: SDK
+  singleTagPerOp: false
+  telemetryEnabled: false
+go:
+  version: 0.0.1
+  packageName: github.com/test/repo
+`
+
+const openAPIDoc = `openapi: 3.0.3
+info:
+  title: Test
+  version: 1.0.0
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      responses:
+        "200":
+          description: OK
+`
+
+type fakePullRequest struct {
+	Number  int    `json:"number"`
+	State   string `json:"state"`
+	Title   string `json:"title"`
+	Body    string `json:"body"`
+	HTMLURL string `json:"html_url"`
+	Head    struct {
+		Ref  string `json:"ref"`
+		Repo struct {
+			FullName string `json:"full_name"`
+		} `json:"repo"`
+	} `json:"head"`
+	Base struct {
+		Ref string `json:"ref"`
+	} `json:"base"`
+	Draft bool `json:"draft"`
+
+	// Labels, Assignees and Reviewers are recorded by name rather than returned in GitHub's object format
+	Labels    []string `json:"-"`
+	Assignees []string `json:"-"`
+	Reviewers []string `json:"-"`
+	// AutoMerge is the merge method auto-merge is enabled with, empty when disabled
+	AutoMerge string   `json:"-"`
+	Comments  []string `json:"-"`
+}
+
+type fakeRelease struct {
+	ID              int64  `json:"id"`
+	TagName         string `json:"tag_name"`
+	TargetCommitish string `json:"target_commitish"`
+	Name            string `json:"name"`
+	Body            string `json:"body"`
+}
+
+// fakeGitHub is an in memory fake of the GitHub REST endpoints used by the action
+type fakeGitHub struct {
+	*httptest.Server
+
+	t            *testing.T
+	cliTar       []byte
+	cliDownloads int
+	mu           sync.Mutex
+	pulls        []*fakePullRequest
+	release      []fakeRelease
+
+	// appKey verifies the JWTs installation tokens are minted with, only set when testing GitHub App authentication
+	appKey             *rsa.PublicKey
+	installationTokens int
+	// authorizations are the Authorization headers sent to the SDK repo's endpoints
+	authorizations map[string]bool
+}
+
+func newFakeGitHub(t *testing.T) *fakeGitHub {
+	t.Helper()
+
+	f := &fakeGitHub{
+		t:              t,
+		cliTar:         buildCLITarball(t),
+		authorizations: map[string]bool{},
+	}
+	f.Server = httptest.NewServer(http.HandlerFunc(f.handle))
+	t.Cleanup(f.Close)
+
+	return f
+}
+
+func (f *fakeGitHub) PullRequests() []fakePullRequest {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	out := []fakePullRequest{}
+	for _, pr := range f.pulls {
+		out = append(out, *pr)
+	}
+
+	return out
+}
+
+// OpenPullRequest opens a PR as if created by an earlier run of the action or a user of the repo
+func (f *fakeGitHub) OpenPullRequest(head, title, body string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	pr := &fakePullRequest{Number: len(f.pulls) + 1, State: "open", Title: title, Body: body}
+	pr.Head.Ref = head
+	pr.Head.Repo.FullName = owner + "/" + repoName
+	pr.Base.Ref = "main"
+	pr.HTMLURL = fmt.Sprintf("%s/%s/%s/pull/%d", f.URL, owner, repoName, pr.Number)
+	f.pulls = append(f.pulls, pr)
+
+	return pr.Number
+}
+
+// LabelPullRequest adds a label to the PR as if added by someone other than the action
+func (f *fakeGitHub) LabelPullRequest(number int, label string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.pulls[number-1].Labels = appendMissing(f.pulls[number-1].Labels, label)
+}
+
+func (f *fakeGitHub) CLIDownloads() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.cliDownloads
+}
+
+// EditRelease changes the body of the release for the tag as if edited by a user of the repo
+func (f *fakeGitHub) EditRelease(tagName, body string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for i := range f.release {
+		if f.release[i].TagName == tagName {
+			f.release[i].Body = body
+		}
+	}
+}
+
+func (f *fakeGitHub) Releases() []fakeRelease {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]fakeRelease{}, f.release...)
+}
+
+// SetAppKey enables minting installation tokens for the GitHub App with the given key
+func (f *fakeGitHub) SetAppKey(key *rsa.PublicKey) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.appKey = key
+}
+
+func (f *fakeGitHub) InstallationTokens() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.installationTokens
+}
+
+func (f *fakeGitHub) Authorizations() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	out := []string{}
+	for authorization := range f.authorizations {
+		out = append(out, authorization)
+	}
+	sort.Strings(out)
+
+	return out
+}
+
+func (f *fakeGitHub) handle(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	cliRepo := "/repos/speakeasy-api/speakeasy"
+	sdkRepo := fmt.Sprintf("/repos/%s/%s", owner, repoName)
+
+	if strings.HasPrefix(r.URL.Path, sdkRepo+"/") {
+		f.authorizations[r.Header.Get("Authorization")] = true
+	}
+	checksumsName := fmt.Sprintf("speakeasy_%s_checksums.txt", speakeasyVersion)
+
+	// The stub cli is a shell script so the same archive is published for every platform
+	assetNames := []string{}
+	for _, platform := range []string{"Linux_x86_64", "Linux_arm64", "Darwin_x86_64", "Darwin_arm64"} {
+		assetNames = append(assetNames, fmt.Sprintf("speakeasy_%s_%s.tar.gz", speakeasyVersion, platform))
+	}
+
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == cliRepo+"/releases":
+		f.writeJSON(w, http.StatusOK, []map[string]any{
+			{"tag_name": "v1.19.0"},
+			{"tag_name": "v" + speakeasyVersion},
+			{"tag_name": "v1.21.0-rc.1", "prerelease": true},
+			{"tag_name": "v2.0.0", "draft": true},
+		})
+	case r.Method == http.MethodGet && r.URL.Path == cliRepo+"/releases/latest":
+		f.writeJSON(w, http.StatusOK, map[string]any{"tag_name": "v" + speakeasyVersion})
+	case r.Method == http.MethodGet && r.URL.Path == cliRepo+"/releases/tags/v"+speakeasyVersion:
+		assets := []map[string]string{
+			{"name": checksumsName, "browser_download_url": f.URL + "/downloads/" + checksumsName},
+		}
+		for _, name := range assetNames {
+			assets = append(assets, map[string]string{"name": name, "browser_download_url": f.URL + "/downloads/" + name})
+		}
+
+		f.writeJSON(w, http.StatusOK, map[string]any{
+			"tag_name": "v" + speakeasyVersion,
+			"assets":   assets,
+		})
+	case r.Method == http.MethodGet && r.URL.Path == "/downloads/"+checksumsName:
+		for _, name := range assetNames {
+			_, _ = fmt.Fprintf(w, "%x  %s\n", sha256.Sum256(f.cliTar), name)
+		}
+	case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/downloads/speakeasy_"):
+		f.cliDownloads++
+		_, _ = w.Write(f.cliTar)
+	case r.Method == http.MethodGet && r.URL.Path == sdkRepo+"/pulls":
+		open := []*fakePullRequest{}
+		for _, pr := range f.pulls {
+			if pr.State == "open" {
+				open = append(open, pr)
+			}
+		}
+		f.writeJSON(w, http.StatusOK, open)
+	case r.Method == http.MethodPost && r.URL.Path == sdkRepo+"/pulls":
+		var req struct {
+			Title string `json:"title"`
+			Body  string `json:"body"`
+			Head  string `json:"head"`
+			Base  string `json:"base"`
+			Draft bool   `json:"draft"`
+		}
+		if !f.readJSON(w, r, &req) {
+			return
+		}
+
+		pr := &fakePullRequest{Number: len(f.pulls) + 1, State: "open", Title: req.Title, Body: req.Body, Draft: req.Draft}
+		pr.Head.Ref = req.Head
+		pr.Head.Repo.FullName = owner + "/" + repoName
+		pr.Base.Ref = req.Base
+		pr.HTMLURL = fmt.Sprintf("%s/%s/%s/pull/%d", f.URL, owner, repoName, pr.Number)
+		f.pulls = append(f.pulls, pr)
+
+		f.writeJSON(w, http.StatusCreated, pr)
+	case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, sdkRepo+"/pulls/"):
+		number, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, sdkRepo+"/pulls/"))
+		if err != nil || number < 1 || number > len(f.pulls) {
+			http.NotFound(w, r)
+			return
+		}
+
+		pr := f.pulls[number-1]
+		f.writeJSON(w, http.StatusOK, map[string]any{"number": pr.Number, "node_id": fmt.Sprintf("PR_%d", pr.Number)})
+	case r.Method == http.MethodPost && r.URL.Path == "/graphql":
+		var req struct {
+			Query     string `json:"query"`
+			Variables struct {
+				ID     string `json:"id"`
+				Method string `json:"method"`
+			} `json:"variables"`
+		}
+		if !f.readJSON(w, r, &req) {
+			return
+		}
+
+		var number int
+		if _, err := fmt.Sscanf(req.Variables.ID, "PR_%d", &number); err != nil || number < 1 || number > len(f.pulls) {
+			f.writeJSON(w, http.StatusOK, map[string]any{"errors": []map[string]string{{"message": "Could not resolve to a node"}}})
+			return
+		}
+
+		switch {
+		case strings.Contains(req.Query, "enablePullRequestAutoMerge"):
+			if f.pulls[number-1].Draft {
+				f.writeJSON(w, http.StatusOK, map[string]any{"errors": []map[string]string{{"message": "Pull request is in draft state"}}})
+				return
+			}
+			f.pulls[number-1].AutoMerge = req.Variables.Method
+		case strings.Contains(req.Query, "disablePullRequestAutoMerge"):
+			f.pulls[number-1].AutoMerge = ""
+		case strings.Contains(req.Query, "convertPullRequestToDraft"):
+			f.pulls[number-1].Draft = true
+			f.pulls[number-1].AutoMerge = ""
+		case strings.Contains(req.Query, "markPullRequestReadyForReview"):
+			f.pulls[number-1].Draft = false
+		default:
+			f.t.Errorf("unexpected graphql query: %s", req.Query)
+		}
+
+		f.writeJSON(w, http.StatusOK, map[string]any{"data": map[string]any{}})
+	case r.Method == http.MethodPatch && strings.HasPrefix(r.URL.Path, sdkRepo+"/pulls/"):
+		number, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, sdkRepo+"/pulls/"))
+		if err != nil || number < 1 || number > len(f.pulls) {
+			http.NotFound(w, r)
+			return
+		}
+
+		var req struct {
+			Title *string `json:"title"`
+			Body  *string `json:"body"`
+			State *string `json:"state"`
+		}
+		if !f.readJSON(w, r, &req) {
+			return
+		}
+
+		pr := f.pulls[number-1]
+		if req.Title != nil {
+			pr.Title = *req.Title
+		}
+		if req.Body != nil {
+			pr.Body = *req.Body
+		}
+		if req.State != nil {
+			pr.State = *req.State
+		}
+
+		f.writeJSON(w, http.StatusOK, pr)
+	case r.Method == http.MethodPost && strings.HasPrefix(r.URL.Path, sdkRepo+"/issues/"):
+		var number int
+		var kind string
+		if _, err := fmt.Sscanf(strings.TrimPrefix(r.URL.Path, sdkRepo+"/issues/"), "%d/%s", &number, &kind); err != nil || number < 1 || number > len(f.pulls) {
+			http.NotFound(w, r)
+			return
+		}
+		pr := f.pulls[number-1]
+
+		switch kind {
+		case "labels":
+			var labels []string
+			if !f.readJSON(w, r, &labels) {
+				return
+			}
+			pr.Labels = appendMissing(pr.Labels, labels...)
+
+			out := []map[string]string{}
+			for _, label := range pr.Labels {
+				out = append(out, map[string]string{"name": label})
+			}
+			f.writeJSON(w, http.StatusOK, out)
+			return
+		case "assignees":
+			var req struct {
+				Assignees []string `json:"assignees"`
+			}
+			if !f.readJSON(w, r, &req) {
+				return
+			}
+			pr.Assignees = appendMissing(pr.Assignees, req.Assignees...)
+		case "comments":
+			var req struct {
+				Body string `json:"body"`
+			}
+			if !f.readJSON(w, r, &req) {
+				return
+			}
+			pr.Comments = append(pr.Comments, req.Body)
+		default:
+			http.NotFound(w, r)
+			return
+		}
+
+		f.writeJSON(w, http.StatusOK, map[string]any{"number": number})
+	case r.Method == http.MethodDelete && strings.HasPrefix(r.URL.Path, sdkRepo+"/issues/"):
+		var number int
+		var kind string
+		if _, err := fmt.Sscanf(strings.TrimPrefix(r.URL.Path, sdkRepo+"/issues/"), "%d/%s", &number, &kind); err != nil || number < 1 || number > len(f.pulls) {
+			http.NotFound(w, r)
+			return
+		}
+		pr := f.pulls[number-1]
+
+		switch {
+		case strings.HasPrefix(kind, "labels/"):
+			label, err := url.PathUnescape(strings.TrimPrefix(kind, "labels/"))
+			if err != nil || !contains(pr.Labels, label) {
+				f.writeJSON(w, http.StatusNotFound, map[string]string{"message": "Label does not exist"})
+				return
+			}
+			pr.Labels = remove(pr.Labels, label)
+		case kind == "assignees":
+			var req struct {
+				Assignees []string `json:"assignees"`
+			}
+			if !f.readJSON(w, r, &req) {
+				return
+			}
+			pr.Assignees = remove(pr.Assignees, req.Assignees...)
+		default:
+			http.NotFound(w, r)
+			return
+		}
+
+		f.writeJSON(w, http.StatusOK, map[string]any{"number": number})
+	case r.Method == http.MethodDelete && strings.HasPrefix(r.URL.Path, sdkRepo+"/pulls/") && strings.HasSuffix(r.URL.Path, "/requested_reviewers"):
+		number, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, sdkRepo+"/pulls/"), "/requested_reviewers"))
+		if err != nil || number < 1 || number > len(f.pulls) {
+			http.NotFound(w, r)
+			return
+		}
+
+		var req struct {
+			Reviewers     []string `json:"reviewers"`
+			TeamReviewers []string `json:"team_reviewers"`
+		}
+		if !f.readJSON(w, r, &req) {
+			return
+		}
+
+		pr := f.pulls[number-1]
+		pr.Reviewers = remove(pr.Reviewers, req.Reviewers...)
+		for _, team := range req.TeamReviewers {
+			pr.Reviewers = remove(pr.Reviewers, "team:"+team)
+		}
+
+		f.writeJSON(w, http.StatusOK, pr)
+	case r.Method == http.MethodPost && strings.HasPrefix(r.URL.Path, sdkRepo+"/pulls/") && strings.HasSuffix(r.URL.Path, "/requested_reviewers"):
+		number, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, sdkRepo+"/pulls/"), "/requested_reviewers"))
+		if err != nil || number < 1 || number > len(f.pulls) {
+			http.NotFound(w, r)
+			return
+		}
+
+		var req struct {
+			Reviewers     []string `json:"reviewers"`
+			TeamReviewers []string `json:"team_reviewers"`
+		}
+		if !f.readJSON(w, r, &req) {
+			return
+		}
+
+		pr := f.pulls[number-1]
+		pr.Reviewers = appendMissing(pr.Reviewers, req.Reviewers...)
+		for _, team := range req.TeamReviewers {
+			pr.Reviewers = appendMissing(pr.Reviewers, "team:"+team)
+		}
+
+		f.writeJSON(w, http.StatusCreated, pr)
+	case r.Method == http.MethodPost && r.URL.Path == sdkRepo+"/releases":
+		var req fakeRelease
+		if !f.readJSON(w, r, &req) {
+			return
+		}
+
+		for _, release := range f.release {
+			if release.TagName == req.TagName {
+				f.writeJSON(w, http.StatusUnprocessableEntity, map[string]string{"message": "Validation Failed"})
+				return
+			}
+		}
+		req.ID = int64(len(f.release) + 1)
+		f.release = append(f.release, req)
+
+		f.writeJSON(w, http.StatusCreated, req)
+	case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, sdkRepo+"/releases/tags/"):
+		tagName := strings.TrimPrefix(r.URL.Path, sdkRepo+"/releases/tags/")
+
+		for _, release := range f.release {
+			if release.TagName == tagName {
+				f.writeJSON(w, http.StatusOK, release)
+				return
+			}
+		}
+		f.writeJSON(w, http.StatusNotFound, map[string]string{"message": "Not Found"})
+	case r.Method == http.MethodPatch && strings.HasPrefix(r.URL.Path, sdkRepo+"/releases/"):
+		id, err := strconv.ParseInt(strings.TrimPrefix(r.URL.Path, sdkRepo+"/releases/"), 10, 64)
+		if err != nil || id < 1 || int(id) > len(f.release) {
+			f.writeJSON(w, http.StatusNotFound, map[string]string{"message": "Not Found"})
+			return
+		}
+
+		var req fakeRelease
+		if !f.readJSON(w, r, &req) {
+			return
+		}
+
+		release := &f.release[id-1]
+		release.Name = req.Name
+		release.Body = req.Body
+
+		f.writeJSON(w, http.StatusOK, release)
+	case r.Method == http.MethodPost && r.URL.Path == fmt.Sprintf("/app/installations/%d/access_tokens", appInstallationID) && f.appKey != nil:
+		if err := verifyAppJWT(f.appKey, strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")); err != nil {
+			f.t.Errorf("invalid github app jwt: %v", err)
+			f.writeJSON(w, http.StatusUnauthorized, map[string]string{"message": err.Error()})
+			return
+		}
+
+		f.installationTokens++
+		f.writeJSON(w, http.StatusCreated, map[string]any{
+			"token":      fmt.Sprintf("ghs_installation%d", f.installationTokens),
+			"expires_at": time.Now().Add(time.Hour),
+		})
+	default:
+		f.t.Errorf("unexpected request to fake GitHub API: %s %s", r.Method, r.URL.Path)
+		http.NotFound(w, r)
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+func remove(values []string, remove ...string) []string {
+	out := []string{}
+	for _, v := range values {
+		if !contains(remove, v) {
+			out = append(out, v)
+		}
+	}
+
+	return out
+}
+
+func appendMissing(values []string, add ...string) []string {
+	for _, v := range add {
+		found := false
+		for _, existing := range values {
+			if existing == v {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			values = append(values, v)
+		}
+	}
+
+	return values
+}
+
+// verifyAppJWT checks the JWT was signed by the GitHub App and is currently valid
+func verifyAppJWT(key *rsa.PublicKey, token string) error {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return fmt.Errorf("expected 3 parts, got %d", len(parts))
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return err
+	}
+
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature); err != nil {
+		return err
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return err
+	}
+
+	var claims struct {
+		IssuedAt  int64 `json:"iat"`
+		ExpiresAt int64 `json:"exp"`
+		Issuer    int64 `json:"iss"`
+	}
+	if err := json.Unmarshal(data, &claims); err != nil {
+		return err
+	}
+
+	now := time.Now().Unix()
+	if claims.Issuer != appID || claims.IssuedAt > now || claims.ExpiresAt < now || claims.ExpiresAt-claims.IssuedAt > 600 {
+		return fmt.Errorf("invalid claims %s", data)
+	}
+
+	return nil
+}
+
+func (f *fakeGitHub) readJSON(w http.ResponseWriter, r *http.Request, v any) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		f.t.Errorf("failed to decode request body for %s %s: %v", r.Method, r.URL.Path, err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return false
+	}
+
+	return true
+}
+
+func (f *fakeGitHub) writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		f.t.Errorf("failed to encode response: %v", err)
+	}
+}
+
+func buildCLITarball(t *testing.T) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+
+	require.NoError(t, tw.WriteHeader(&tar.Header{
+		Name:     "speakeasy",
+		Mode:     0o755,
+		Size:     int64(len(stubSpeakeasy)),
+		Typeflag: tar.TypeReg,
+	}))
+	_, err := io.WriteString(tw, stubSpeakeasy)
+	require.NoError(t, err)
+
+	require.NoError(t, tw.Close())
+	require.NoError(t, gw.Close())
+
+	return buf.Bytes()
+}
+
+// harness runs the action binary against a local bare repo and the fake GitHub API
+type harness struct {
+	t         *testing.T
+	dir       string
+	serverURL string
+	remote    string
+	github    *fakeGitHub
+	eventPath string
+	runs      int
+}
+
+// activeGitHub is the fake GitHub of the running test, which serves the Speakeasy CLI releases as the tests don't run in parallel
+var activeGitHub atomic.Pointer[fakeGitHub]
+
+// serveSpeakeasyReleases serves the Speakeasy CLI releases API the action binary is built with from the fake GitHub of the running test
+func serveSpeakeasyReleases(w http.ResponseWriter, r *http.Request) {
+	f := activeGitHub.Load()
+	if f == nil {
+		http.Error(w, "no test running", http.StatusServiceUnavailable)
+		return
+	}
+
+	f.handle(w, r)
+}
+
+func newHarness(t *testing.T) *harness {
+	t.Helper()
+
+	dir := t.TempDir()
+
+	h := &harness{
+		t:         t,
+		dir:       dir,
+		serverURL: "file://" + filepath.Join(dir, "remote"),
+		remote:    filepath.Join(dir, "remote", owner, repoName),
+		github:    newFakeGitHub(t),
+	}
+
+	activeGitHub.Store(h.github)
+	t.Cleanup(func() { activeGitHub.CompareAndSwap(h.github, nil) })
+
+	seed := filepath.Join(dir, "seed")
+	require.NoError(t, os.MkdirAll(seed, os.ModePerm))
+	require.NoError(t, os.WriteFile(filepath.Join(seed, "gen.yaml"), []byte(genYAML), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(seed, "openapi.yaml"), []byte(openAPIDoc), 0o644))
+
+	h.git(seed, "init", "--initial-branch=main")
+	h.git(seed, "add", ".")
+	h.git(seed, "commit", "-m", "initial commit")
+	h.git(dir, "clone", "--bare", seed, h.remote)
+
+	return h
+}
+
+func (h *harness) git(dir string, args ...string) string {
+	h.t.Helper()
+
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), gitIdentityEnv()...)
+
+	out, err := cmd.CombinedOutput()
+	require.NoError(h.t, err, "git %s: %s", strings.Join(args, " "), out)
+
+	return strings.TrimSpace(string(out))
+}
+
+// remoteGit runs a git command against the bare repo acting as the remote
+func (h *harness) remoteGit(args ...string) string {
+	h.t.Helper()
+
+	return h.git(h.remote, args...)
+}
+
+func (h *harness) head(ref string) string {
+	h.t.Helper()
+
+	return h.remoteGit("rev-parse", ref)
+}
+
+func (h *harness) branches() []string {
+	h.t.Helper()
+
+	return strings.Fields(h.remoteGit("for-each-ref", "--format=%(refname:short)", "refs/heads"))
+}
+
+// mergePR simulates merging a PR on the forge by fast forwarding main to the PR branch
+func (h *harness) mergePR(branchName string) {
+	h.t.Helper()
+
+	h.remoteGit("update-ref", "refs/heads/main", "refs/heads/"+branchName)
+}
+
+// commitFiles commits the files to main in the remote, as if pushed by a user of the repo
+func (h *harness) commitFiles(files map[string]string) {
+	h.t.Helper()
+
+	h.runs++
+	checkout := filepath.Join(h.dir, fmt.Sprintf("checkout-%d", h.runs))
+	h.git(h.dir, "clone", h.remote, checkout)
+
+	for name, content := range files {
+		require.NoError(h.t, os.MkdirAll(filepath.Dir(filepath.Join(checkout, name)), os.ModePerm))
+		require.NoError(h.t, os.WriteFile(filepath.Join(checkout, name), []byte(content), 0o644))
+	}
+
+	h.git(checkout, "add", ".")
+	h.git(checkout, "commit", "-m", "add files")
+	h.git(checkout, "push", "origin", "main")
+}
+
+// run runs the action with the given inputs in a fresh working directory and returns its outputs
+func (h *harness) run(inputs map[string]string) (map[string]string, error) {
+	h.t.Helper()
+
+	h.runs++
+	workDir := filepath.Join(h.dir, fmt.Sprintf("run-%d", h.runs))
+	require.NoError(h.t, os.MkdirAll(workDir, os.ModePerm))
+
+	outputPath := filepath.Join(workDir, "output.txt")
+
+	env := append([]string{
+		"PATH=" + os.Getenv("PATH"),
+		"HOME=" + h.dir,
+		"SPEAKEASY_ENVIRONMENT=local",
+		"GITHUB_API_URL=" + h.github.URL,
+		"GITHUB_SERVER_URL=" + h.serverURL,
+		"GITHUB_REPOSITORY=" + owner + "/" + repoName,
+		"GITHUB_REPOSITORY_OWNER=" + owner,
+		"GITHUB_REF=refs/heads/main",
+		"GITHUB_WORKFLOW=test",
+		"GITHUB_RUN_ID=" + runID,
+		"GITHUB_OUTPUT=" + outputPath,
+		"INPUT_GITHUB_ACCESS_TOKEN=fake-token",
+		"INPUT_OPENAPI_DOC_LOCATION=openapi.yaml",
+		"INPUT_LANGUAGES=- go",
+	}, gitIdentityEnv()...)
+	if h.eventPath != "" {
+		env = append(env, "GITHUB_EVENT_PATH="+h.eventPath)
+	}
+	for k, v := range inputs {
+		env = append(env, fmt.Sprintf("INPUT_%s=%s", strings.ToUpper(k), v))
+	}
+
+	cmd := exec.Command(actionBinary)
+	cmd.Dir = workDir
+	cmd.Env = env
+
+	out, err := cmd.CombinedOutput()
+	h.t.Logf("action %s output:\n%s", inputs["action"], out)
+	if err != nil {
+		return nil, fmt.Errorf("action %s failed: %w", inputs["action"], err)
+	}
+
+	return readOutputs(h.t, outputPath), nil
+}
+
+// lockRef is the ref of the concurrency lock on main
+const lockRef = "refs/speakeasy/locks/main"
+
+// lock returns the owner and expiry of the concurrency lock on main, or an empty owner if the lock isn't held
+func (h *harness) lock() (string, time.Time) {
+	h.t.Helper()
+
+	if h.remoteGit("for-each-ref", lockRef) == "" {
+		return "", time.Time{}
+	}
+
+	var l struct {
+		Owner     string    `json:"owner"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+	require.NoError(h.t, json.Unmarshal([]byte(h.remoteGit("log", "-1", "--format=%B", lockRef)), &l))
+
+	return l.Owner, l.ExpiresAt
+}
+
+// setLock makes the concurrency lock on main held by another workflow run until the given time
+func (h *harness) setLock(owner string, expiresAt time.Time) {
+	h.t.Helper()
+
+	data, err := json.Marshal(map[string]any{"owner": owner, "workflow": "other", "acquired_at": time.Now(), "expires_at": expiresAt})
+	require.NoError(h.t, err)
+
+	// The empty tree is always known to git
+	commit := h.remoteGit("commit-tree", "4b825dc642cb6eb9a060e54bf8d69288fbee4904", "-m", string(data))
+	h.remoteGit("update-ref", lockRef, commit)
+}
+
+// setPushEvent writes the payload of a push event for the given range of commits, as used by the release action
+func (h *harness) setPushEvent(before, after string) {
+	h.t.Helper()
+
+	path := filepath.Join(h.dir, fmt.Sprintf("event-%d.json", h.runs))
+
+	data, err := json.Marshal(map[string]string{"before": before, "after": after})
+	require.NoError(h.t, err)
+	require.NoError(h.t, os.WriteFile(path, data, 0o644))
+
+	h.eventPath = path
+}
+
+func readOutputs(t *testing.T, path string) map[string]string {
+	t.Helper()
+
+	outputs := map[string]string{}
+
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return outputs
+	}
+	require.NoError(t, err)
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		k, v, ok := strings.Cut(scanner.Text(), "=")
+		if ok {
+			outputs[k] = v
+		}
+	}
+	require.NoError(t, scanner.Err())
+
+	return outputs
+}
+
+func gitIdentityEnv() []string {
+	return []string{
+		"GIT_AUTHOR_NAME=test",
+		"GIT_AUTHOR_EMAIL=test@example.com",
+		"GIT_COMMITTER_NAME=test",
+		"GIT_COMMITTER_EMAIL=test@example.com",
+	}
+}